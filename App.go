@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"github.com/devtron-labs/central-api/api"
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/pkg"
 	"go.uber.org/zap"
 	"net/http"
 	"os"
@@ -27,15 +29,22 @@ import (
 )
 
 type App struct {
-	MuxRouter *api.MuxRouter
-	Logger    *zap.SugaredLogger
-	server    *http.Server
+	MuxRouter               *api.MuxRouter
+	Logger                  *zap.SugaredLogger
+	server                  *http.Server
+	webhookQueueService     pkg.WebhookQueueService
+	webhookDeliveryConfig   *util.WebhookDeliveryConfig
+	releaseReconcileService pkg.ReleaseReconcileService
 }
 
-func NewApp(MuxRouter *api.MuxRouter, Logger *zap.SugaredLogger) *App {
+func NewApp(MuxRouter *api.MuxRouter, Logger *zap.SugaredLogger, webhookQueueService pkg.WebhookQueueService,
+	webhookDeliveryConfig *util.WebhookDeliveryConfig, releaseReconcileService pkg.ReleaseReconcileService) *App {
 	return &App{
-		MuxRouter: MuxRouter,
-		Logger:    Logger,
+		MuxRouter:               MuxRouter,
+		Logger:                  Logger,
+		webhookQueueService:     webhookQueueService,
+		webhookDeliveryConfig:   webhookDeliveryConfig,
+		releaseReconcileService: releaseReconcileService,
 	}
 }
 
@@ -46,7 +55,7 @@ func (app *App) Start() {
 	server := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: app.MuxRouter.Router}
 	app.server = server
 	err := server.ListenAndServe()
-	if err != nil {
+	if err != nil && err != http.ErrServerClosed {
 		app.Logger.Errorw("error in startup", "err", err)
 		os.Exit(2)
 	}
@@ -54,7 +63,8 @@ func (app *App) Start() {
 
 func (app *App) Stop() {
 	app.Logger.Infow("lens shutdown initiating")
-	timeoutContext, _ := context.WithTimeout(context.Background(), 5*time.Second)
+	timeoutContext, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	app.Logger.Infow("stopping nats")
 
 	app.Logger.Infow("closing router")
@@ -62,6 +72,16 @@ func (app *App) Stop() {
 	if err != nil {
 		app.Logger.Errorw("error in mux router shutdown", "err", err)
 	}
+	app.Logger.Infow("stopping release reconcile")
+	app.releaseReconcileService.Stop()
+	// router is closed first so that no new deliveries are queued while draining
+	app.Logger.Infow("draining webhook queue")
+	drainContext, drainCancel := context.WithTimeout(context.Background(), app.webhookDeliveryConfig.DrainTimeout)
+	defer drainCancel()
+	err = app.webhookQueueService.Stop(drainContext)
+	if err != nil {
+		app.Logger.Errorw("error in draining webhook queue", "err", err)
+	}
 	app.Logger.Infow("closing db connection")
 	app.Logger.Infow("housekeeping done. exiting now")
 }
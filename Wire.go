@@ -24,6 +24,10 @@ import (
 	util "github.com/devtron-labs/central-api/client"
 	"github.com/devtron-labs/central-api/internal/logger"
 	"github.com/devtron-labs/central-api/pkg"
+	"github.com/devtron-labs/central-api/pkg/blobStorage"
+	"github.com/devtron-labs/central-api/pkg/lock"
+	"github.com/devtron-labs/central-api/pkg/sql"
+	"github.com/devtron-labs/central-api/pkg/webhookDelivery"
 	blob_storage "github.com/devtron-labs/common-lib/blob-storage"
 	"github.com/google/wire"
 )
@@ -35,19 +39,49 @@ func InitializeApp() (*App, error) {
 		//releaseNote.NewReleaseNoteRepositoryImpl,
 		//wire.Bind(new(releaseNote.ReleaseNoteRepository), new(*releaseNote.ReleaseNoteRepositoryImpl)),
 		blob_storage.NewBlobStorageServiceImpl,
+		util.NewStorageConfig,
+		blobStorage.NewBlobAdapter,
+		sql.NewStorageDbConnection,
 		NewApp,
 		api.NewMuxRouter,
 		util.NewGitHubClient,
 		//logger.NewHttpClient,
 		api.NewRestHandlerImpl,
 		wire.Bind(new(api.RestHandler), new(*api.RestHandlerImpl)),
+		util.NewReleaseChannelConfig,
+		pkg.NewReleaseChannelClassifierImpl,
+		wire.Bind(new(pkg.ReleaseChannelClassifier), new(*pkg.ReleaseChannelClassifierImpl)),
+		pkg.NewReleaseRepositoryRegistryImpl,
+		wire.Bind(new(pkg.ReleaseRepositoryRegistry), new(*pkg.ReleaseRepositoryRegistryImpl)),
 		pkg.NewReleaseNoteServiceImpl,
 		wire.Bind(new(pkg.ReleaseNoteService), new(*pkg.ReleaseNoteServiceImpl)),
 		pkg.NewWebhookSecretValidatorImpl,
 		wire.Bind(new(pkg.WebhookSecretValidator), new(*pkg.WebhookSecretValidatorImpl)),
+		util.NewReleaseSourceConfig,
+		pkg.NewReleaseSource,
 		util.NewModuleConfig,
 		util.NewBlobConfig,
 
+		pkg.NewUpgradePathServiceImpl,
+		wire.Bind(new(pkg.UpgradePathService), new(*pkg.UpgradePathServiceImpl)),
+
+		pkg.NewReleaseNoteRenderServiceImpl,
+		wire.Bind(new(pkg.ReleaseNoteRenderService), new(*pkg.ReleaseNoteRenderServiceImpl)),
+
+		util.NewWebhookDeliveryConfig,
+		webhookDelivery.NewWebhookDeliveryRepository,
+		pkg.NewWebhookDeliveryServiceImpl,
+		wire.Bind(new(pkg.WebhookDeliveryService), new(*pkg.WebhookDeliveryServiceImpl)),
+		pkg.NewWebhookQueueServiceImpl,
+		wire.Bind(new(pkg.WebhookQueueService), new(*pkg.WebhookQueueServiceImpl)),
+		pkg.NewWebhookEventRouterImpl,
+		wire.Bind(new(pkg.WebhookEventRouter), new(*pkg.WebhookEventRouterImpl)),
+
+		util.NewReconcileConfig,
+		lock.NewDistributedLock,
+		pkg.NewReleaseReconcileServiceImpl,
+		wire.Bind(new(pkg.ReleaseReconcileService), new(*pkg.ReleaseReconcileServiceImpl)),
+
 		pkg.NewCiBuildMetadataServiceImpl,
 		wire.Bind(new(pkg.CiBuildMetadataService), new(*pkg.CiBuildMetadataServiceImpl)),
 	)
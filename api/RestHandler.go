@@ -17,7 +17,9 @@
 package api
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"fmt"
 	util "github.com/devtron-labs/central-api/client"
 	"github.com/devtron-labs/central-api/common"
 	"github.com/devtron-labs/central-api/pkg"
@@ -26,11 +28,19 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type RestHandler interface {
 	GetReleases(w http.ResponseWriter, r *http.Request)
+	GetReleasesV2(w http.ResponseWriter, r *http.Request)
+	GetCombinedReleases(w http.ResponseWriter, r *http.Request)
+	GetLatestRelease(w http.ResponseWriter, r *http.Request)
+	GetReleasesInRange(w http.ResponseWriter, r *http.Request)
+	GetUpgradePath(w http.ResponseWriter, r *http.Request)
 	ReleaseWebhookHandler(w http.ResponseWriter, r *http.Request)
+	ListWebhookDeliveries(w http.ResponseWriter, r *http.Request)
+	ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request)
 	GetModules(w http.ResponseWriter, r *http.Request)
 	GetModulesV2(w http.ResponseWriter, r *http.Request)
 	GetModuleByName(w http.ResponseWriter, r *http.Request)
@@ -39,22 +49,35 @@ type RestHandler interface {
 }
 
 func NewRestHandlerImpl(logger *zap.SugaredLogger, releaseNoteService pkg.ReleaseNoteService,
-	webhookSecretValidator pkg.WebhookSecretValidator, client *util.GitHubClient, ciBuildMetadataService pkg.CiBuildMetadataService) *RestHandlerImpl {
+	releaseSource pkg.ReleaseSource, client *util.GitHubClient, ciBuildMetadataService pkg.CiBuildMetadataService,
+	upgradePathService pkg.UpgradePathService, releaseNoteRenderService pkg.ReleaseNoteRenderService,
+	webhookDeliveryService pkg.WebhookDeliveryService, webhookDeliveryConfig *util.WebhookDeliveryConfig,
+	webhookEventRouter pkg.WebhookEventRouter) *RestHandlerImpl {
 	return &RestHandlerImpl{
-		logger:                 logger,
-		releaseNoteService:     releaseNoteService,
-		webhookSecretValidator: webhookSecretValidator,
-		client:                 client,
-		ciBuildMetadataService: ciBuildMetadataService,
+		logger:                   logger,
+		releaseNoteService:       releaseNoteService,
+		releaseSource:            releaseSource,
+		client:                   client,
+		ciBuildMetadataService:   ciBuildMetadataService,
+		upgradePathService:       upgradePathService,
+		releaseNoteRenderService: releaseNoteRenderService,
+		webhookDeliveryService:   webhookDeliveryService,
+		webhookDeliveryConfig:    webhookDeliveryConfig,
+		webhookEventRouter:       webhookEventRouter,
 	}
 }
 
 type RestHandlerImpl struct {
-	logger                 *zap.SugaredLogger
-	releaseNoteService     pkg.ReleaseNoteService
-	webhookSecretValidator pkg.WebhookSecretValidator
-	client                 *util.GitHubClient
-	ciBuildMetadataService pkg.CiBuildMetadataService
+	logger                   *zap.SugaredLogger
+	releaseNoteService       pkg.ReleaseNoteService
+	releaseSource            pkg.ReleaseSource
+	client                   *util.GitHubClient
+	ciBuildMetadataService   pkg.CiBuildMetadataService
+	upgradePathService       pkg.UpgradePathService
+	releaseNoteRenderService pkg.ReleaseNoteRenderService
+	webhookDeliveryService   pkg.WebhookDeliveryService
+	webhookDeliveryConfig    *util.WebhookDeliveryConfig
+	webhookEventRouter       pkg.WebhookEventRouter
 }
 
 func setupResponse(w *http.ResponseWriter, req *http.Request) {
@@ -112,53 +135,196 @@ func (impl *RestHandlerImpl) GetModulesV2(w http.ResponseWriter, r *http.Request
 	return
 }
 
-func (impl *RestHandlerImpl) GetReleases(w http.ResponseWriter, r *http.Request) {
-	setupResponse(&w, r)
-	impl.logger.Debug("get all releases")
-	offset := 0
-	size := 10
+// getPaginationParams reads offset and size query params, writes bad request response on invalid values.
+// Size is capped at pkg.MaxReleasesPerPage.
+func (impl *RestHandlerImpl) getPaginationParams(w http.ResponseWriter, r *http.Request) (offset int, size int, ok bool) {
+	offset = 0
+	size = 10
 	var err error
 	offsetQueryParam := r.URL.Query().Get("offset")
 	if len(offsetQueryParam) > 0 {
 		offset, err = strconv.Atoi(offsetQueryParam)
+		if err == nil && offset < 0 {
+			err = fmt.Errorf("offset can not be negative")
+		}
 		if err != nil {
 			impl.WriteJsonResp(w, err, "invalid offset", http.StatusBadRequest)
-			return
+			return offset, size, false
 		}
 	}
 	sizeQueryParam := r.URL.Query().Get("size")
 	if len(sizeQueryParam) > 0 {
 		size, err = strconv.Atoi(sizeQueryParam)
+		if err == nil && size < 0 {
+			err = fmt.Errorf("size can not be negative")
+		}
 		if err != nil {
 			impl.WriteJsonResp(w, err, "invalid size", http.StatusBadRequest)
-			return
+			return offset, size, false
 		}
 	}
-	//will fetch all the releases from cache and later apply size and offset filter
-	response, err := impl.releaseNoteService.GetReleases()
+	// size 0 returns everything after offset as before, pages are capped otherwise
+	if size > pkg.MaxReleasesPerPage {
+		size = pkg.MaxReleasesPerPage
+	}
+	return offset, size, true
+}
+
+// GetReleases serves releases of repo and channel query params, primary repo and default channel when they are not set
+func (impl *RestHandlerImpl) GetReleases(w http.ResponseWriter, r *http.Request) {
+	setupResponse(&w, r)
+	impl.logger.Debug("get all releases")
+	repo := r.URL.Query().Get("repo")
+	channel := r.URL.Query().Get("channel")
+	impl.writeReleases(w, r, func(offset int, size int) ([]*common.Release, error) {
+		return impl.releaseNoteService.GetReleases(repo, channel, offset, size)
+	})
+}
+
+// GetCombinedReleases serves releases of every configured repo latest published first
+func (impl *RestHandlerImpl) GetCombinedReleases(w http.ResponseWriter, r *http.Request) {
+	setupResponse(&w, r)
+	impl.logger.Debug("get combined releases")
+	channel := r.URL.Query().Get("channel")
+	impl.writeReleases(w, r, func(offset int, size int) ([]*common.Release, error) {
+		return impl.releaseNoteService.GetCombinedReleases(channel, offset, size)
+	})
+}
+
+// GetLatestRelease serves latest release of repo in feed of channel, 404 if channel has no release yet
+func (impl *RestHandlerImpl) GetLatestRelease(w http.ResponseWriter, r *http.Request) {
+	setupResponse(&w, r)
+	impl.logger.Debug("get latest release")
+	format := r.URL.Query().Get("format")
+	if len(format) > 0 && !pkg.IsValidReleaseFormat(format) {
+		impl.WriteJsonResp(w, fmt.Errorf("unsupported format %s", format), "invalid format, supported formats are html, markdown and text", http.StatusBadRequest)
+		return
+	}
+	channel := r.URL.Query().Get("channel")
+	release, err := impl.releaseNoteService.GetLatestRelease(r.URL.Query().Get("repo"), channel)
+	if isInvalidReleaseQueryError(err) {
+		impl.WriteJsonResp(w, err, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		impl.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
 		return
 	}
+	if release == nil {
+		impl.WriteJsonResp(w, fmt.Errorf("no release found in channel %s", channel), "no release found", http.StatusNotFound)
+		return
+	}
+	response, err := impl.releaseNoteRenderService.Render([]*common.Release{release}, format)
+	if err != nil {
+		impl.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	impl.WriteJsonResp(w, nil, response[0], http.StatusOK)
+}
 
-	if size > 0 {
-		if offset+size <= len(response) {
-			response = response[offset : offset+size]
-		} else {
-			response = response[offset:]
-		}
+// isInvalidReleaseQueryError returns true for errors caused by repo, channel or version query params
+func isInvalidReleaseQueryError(err error) bool {
+	switch err.(type) {
+	case *pkg.UnknownRepoError, *pkg.UnknownChannelError, *pkg.InvalidVersionError:
+		return true
+	}
+	return false
+}
+
+// writeReleases reads pagination and format params, gets releases and writes them rendered in format
+func (impl *RestHandlerImpl) writeReleases(w http.ResponseWriter, r *http.Request, getReleases func(offset int, size int) ([]*common.Release, error)) {
+	offset, size, ok := impl.getPaginationParams(w, r)
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if len(format) > 0 && !pkg.IsValidReleaseFormat(format) {
+		impl.WriteJsonResp(w, fmt.Errorf("unsupported format %s", format), "invalid format, supported formats are html, markdown and text", http.StatusBadRequest)
+		return
+	}
+	response, err := getReleases(offset, size)
+	if isInvalidReleaseQueryError(err) {
+		impl.WriteJsonResp(w, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		impl.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	response, err = impl.releaseNoteRenderService.Render(response, format)
+	if err != nil {
+		impl.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	impl.WriteJsonResp(w, nil, response, http.StatusOK)
+	return
+}
+
+func (impl *RestHandlerImpl) GetReleasesV2(w http.ResponseWriter, r *http.Request) {
+	setupResponse(&w, r)
+	impl.logger.Debug("get all releases v2")
+	offset, size, ok := impl.getPaginationParams(w, r)
+	if !ok {
+		return
+	}
+	response, err := impl.releaseNoteService.GetReleasesV2(r.URL.Query().Get("repo"), r.URL.Query().Get("channel"), offset, size)
+	if isInvalidReleaseQueryError(err) {
+		impl.WriteJsonResp(w, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		impl.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	impl.WriteJsonResp(w, nil, response, http.StatusOK)
+	return
+}
+
+func (impl *RestHandlerImpl) GetReleasesInRange(w http.ResponseWriter, r *http.Request) {
+	setupResponse(&w, r)
+	impl.logger.Debug("get releases in range")
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	response, err := impl.releaseNoteService.GetReleasesInRange(from, to)
+	if isInvalidReleaseQueryError(err) {
+		impl.WriteJsonResp(w, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		impl.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
 	}
+	impl.WriteJsonResp(w, nil, response, http.StatusOK)
+	return
+}
 
+func (impl *RestHandlerImpl) GetUpgradePath(w http.ResponseWriter, r *http.Request) {
+	setupResponse(&w, r)
+	impl.logger.Debug("get upgrade path")
+	current := r.URL.Query().Get("current")
+	target := r.URL.Query().Get("target")
+	response, err := impl.upgradePathService.GetUpgradePath(current, target)
+	if isInvalidReleaseQueryError(err) {
+		impl.WriteJsonResp(w, err, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err == pkg.ErrUpgradeTargetNotFound {
+		impl.WriteJsonResp(w, err, nil, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		impl.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
 	impl.WriteJsonResp(w, nil, response, http.StatusOK)
 	return
 }
 
 func (impl *RestHandlerImpl) ReleaseWebhookHandler(w http.ResponseWriter, r *http.Request) {
 	impl.logger.Debug("release webhook handler received event")
-	// get git host Id and secret from request
-	vars := mux.Vars(r)
-	secretFromRequest := vars["secret"]
-	impl.logger.Debugw("secret found in request", "secret", secretFromRequest)
+	// secret in path is never logged, only its presence
+	_, secretInPath := mux.Vars(r)[pkg.WebhookSecretPathVariable]
+	impl.logger.Debugw("secret found in request path", "secretInPath", secretInPath)
 
 	// validate signature
 	requestBodyBytes, err := ioutil.ReadAll(r.Body)
@@ -168,28 +334,108 @@ func (impl *RestHandlerImpl) ReleaseWebhookHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
-	isValidSig := impl.webhookSecretValidator.ValidateSecret(r, requestBodyBytes)
+	isValidSig := impl.releaseSource.ValidateWebhook(r, requestBodyBytes)
 	impl.logger.Debugw("Secret validation result ", "isValidSig", isValidSig)
+	delivery, isDuplicate, recordErr := impl.webhookDeliveryService.RecordDelivery(r, requestBodyBytes, isValidSig)
 	if !isValidSig {
 		impl.logger.Error("Signature mismatch")
 		impl.WriteJsonResp(w, err, nil, http.StatusUnauthorized)
 		return
 	}
-	// validate event type
-	eventType := r.Header.Get(impl.client.GitHubConfig.GitHubEventTypeHeader)
-	impl.logger.Debugw("webhook event type header", "eventType : ", eventType)
-	if len(eventType) == 0 && eventType != pkg.EventTypeRelease {
-		impl.logger.Errorw("Event type not known ", eventType)
+	if recordErr != nil {
+		// deliveries are applied from delivery log, github retries the delivery on error response
+		impl.logger.Errorw("error in recording webhook delivery", "err", recordErr)
+		impl.WriteJsonResp(w, recordErr, nil, http.StatusInternalServerError)
+		return
+	}
+	if isDuplicate {
+		impl.WriteJsonResp(w, nil, "duplicate delivery skipped", http.StatusOK)
+		return
+	}
+	response, err := impl.webhookEventRouter.Route(delivery)
+	if err == pkg.ErrWebhookEventTypeMissing {
+		impl.logger.Errorw("webhook event type header missing", "deliveryId", delivery.DeliveryId)
 		impl.WriteJsonResp(w, err, nil, http.StatusBadRequest)
 		return
 	}
+	if invalidPayloadError, ok := err.(*pkg.InvalidPayloadError); ok {
+		impl.WriteJsonResp(w, err, invalidPayloadError.FieldErrors, http.StatusBadRequest)
+		return
+	}
+	if err == pkg.ErrWebhookQueueFull || err == pkg.ErrWebhookQueueStopped {
+		impl.WriteJsonResp(w, err, nil, http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		impl.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	if response.Status == http.StatusNoContent {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	impl.WriteJsonResp(w, nil, response.Result, response.Status)
+	return
+}
+
+// isAdminRequest checks bearer token of admin endpoints in constant time, writes error response when not authorized
+func (impl *RestHandlerImpl) isAdminRequest(w http.ResponseWriter, r *http.Request) bool {
+	adminToken := impl.webhookDeliveryConfig.AdminToken
+	if len(adminToken) == 0 {
+		impl.WriteJsonResp(w, fmt.Errorf("admin api is disabled"), nil, http.StatusForbidden)
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+		impl.WriteJsonResp(w, fmt.Errorf("invalid admin token"), nil, http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func (impl *RestHandlerImpl) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	impl.logger.Debug("list webhook deliveries")
+	if !impl.isAdminRequest(w, r) {
+		return
+	}
+	offset, size, ok := impl.getPaginationParams(w, r)
+	if !ok {
+		return
+	}
+	status := r.URL.Query().Get("status")
+	deliveries, err := impl.webhookDeliveryService.ListDeliveries(offset, size, status)
+	if err != nil {
+		impl.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
+		return
+	}
+	impl.WriteJsonResp(w, nil, deliveries, http.StatusOK)
+	return
+}
 
-	flag, err := impl.releaseNoteService.UpdateReleases(requestBodyBytes)
+func (impl *RestHandlerImpl) ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	if !impl.isAdminRequest(w, r) {
+		return
+	}
+	deliveryId := mux.Vars(r)["deliveryId"]
+	impl.logger.Infow("replay webhook delivery", "deliveryId", deliveryId)
+	flag, err := impl.webhookDeliveryService.ReplayDelivery(deliveryId)
+	if err == pkg.ErrWebhookDeliveryNotFound {
+		impl.WriteJsonResp(w, err, nil, http.StatusNotFound)
+		return
+	}
+	if err == pkg.ErrWebhookDeliveryNotReplayable || err == pkg.ErrWebhookEventNotReplayable {
+		impl.WriteJsonResp(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	if invalidPayloadError, ok := err.(*pkg.InvalidPayloadError); ok {
+		impl.WriteJsonResp(w, err, invalidPayloadError.FieldErrors, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		impl.WriteJsonResp(w, err, nil, http.StatusInternalServerError)
 		return
 	}
-	impl.WriteJsonResp(w, err, flag, http.StatusOK)
+	impl.WriteJsonResp(w, nil, flag, http.StatusOK)
 	return
 }
 
@@ -19,6 +19,7 @@ package api
 import (
 	"encoding/json"
 	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/internal/metrics"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"net/http"
@@ -36,7 +37,7 @@ func NewMuxRouter(logger *zap.SugaredLogger, restHandler RestHandler) *MuxRouter
 
 func (r MuxRouter) Init() {
 	r.Router.StrictSlash(true)
-	//r.Router.Handle("/metrics", promhttp.Handler())
+	r.Router.Handle("/metrics", metrics.Handler())
 	r.Router.Path("/health").HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		writer.Header().Set("Content-Type", "application/json")
 		writer.WriteHeader(200)
@@ -52,7 +53,16 @@ func (r MuxRouter) Init() {
 	})
 
 	r.Router.Path("/release/notes").HandlerFunc(r.restHandler.GetReleases).Methods("GET")
+	r.Router.Path("/v2/release/notes").HandlerFunc(r.restHandler.GetReleasesV2).Methods("GET")
+	r.Router.Path("/release/notes/combined").HandlerFunc(r.restHandler.GetCombinedReleases).Methods("GET")
+	r.Router.Path("/release/latest").HandlerFunc(r.restHandler.GetLatestRelease).Methods("GET")
+	r.Router.Path("/release/notes/range").HandlerFunc(r.restHandler.GetReleasesInRange).Methods("GET")
+	r.Router.Path("/upgrade/path").HandlerFunc(r.restHandler.GetUpgradePath).Methods("GET")
 	r.Router.Path("/release/webhook").HandlerFunc(r.restHandler.ReleaseWebhookHandler).Methods("POST")
+	// for git providers which can not sign payloads, secret is appended to url and validated by URL_APPEND validator
+	r.Router.Path("/release/webhook/{secret}").HandlerFunc(r.restHandler.ReleaseWebhookHandler).Methods("POST")
+	r.Router.Path("/admin/webhook/deliveries").HandlerFunc(r.restHandler.ListWebhookDeliveries).Methods("GET")
+	r.Router.Path("/admin/webhook/deliveries/{deliveryId}/replay").HandlerFunc(r.restHandler.ReplayWebhookDelivery).Methods("POST")
 	r.Router.Path("/modules").HandlerFunc(r.restHandler.GetModules).Methods("GET")
 	r.Router.Path("/dockerfileTemplate").HandlerFunc(r.restHandler.GetDockerfileTemplateMetadata).Methods("GET")
 	r.Router.Path("/buildpackMetadata").HandlerFunc(r.restHandler.GetBuildpackMetadata).Methods("GET")
@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"golang.org/x/oauth2"
+	http2 "net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// githubAppJwtLifetime is below the 10 minutes maximum allowed by github
+	githubAppJwtLifetime = 9 * time.Minute
+	// githubAppClockSkew backdates jwt issue time for clocks running ahead of github
+	githubAppClockSkew = time.Minute
+	// githubAppTokenRefreshMargin renews installation token before it expires, tokens are valid for an hour
+	githubAppTokenRefreshMargin = 5 * time.Minute
+)
+
+// GitHubAppTokenSource mints installation access tokens of a github app. Wrapped in oauth2.ReuseTokenSource a
+// token is reused till refresh margin before its expiry and a new one is minted on next request after that.
+type GitHubAppTokenSource struct {
+	appId          int64
+	installationId int64
+	privateKey     *rsa.PrivateKey
+	// apiBaseUrl ends with "/", it is https://api.github.com/ for github.com and <host>/api/v3/ for enterprise
+	apiBaseUrl string
+	httpClient *http2.Client
+}
+
+type githubInstallationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewGitHubAppTokenSource(cfg *GitHubConfig, apiBaseUrl string, httpClient *http2.Client) (*GitHubAppTokenSource, error) {
+	if cfg.GitHubAppInstallationId <= 0 {
+		return nil, errors.New("GITHUB_APP_INSTALLATION_ID is required with GITHUB_APP_ID")
+	}
+	privateKey, err := cfg.getGitHubAppPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(apiBaseUrl, "/") {
+		apiBaseUrl += "/"
+	}
+	return &GitHubAppTokenSource{
+		appId:          cfg.GitHubAppId,
+		installationId: cfg.GitHubAppInstallationId,
+		privateKey:     privateKey,
+		apiBaseUrl:     apiBaseUrl,
+		httpClient:     httpClient,
+	}, nil
+}
+
+func (impl *GitHubAppTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := impl.createJwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	tokenUrl := fmt.Sprintf("%sapp/installations/%d/access_tokens", impl.apiBaseUrl, impl.installationId)
+	req, err := http2.NewRequest(http2.MethodPost, tokenUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := impl.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http2.StatusCreated {
+		return nil, fmt.Errorf("github app installation token request failed with status %d", resp.StatusCode)
+	}
+	installationToken := &githubInstallationToken{}
+	err = json.NewDecoder(resp.Body).Decode(installationToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(installationToken.Token) == 0 {
+		return nil, errors.New("github app installation token missing in response")
+	}
+	return &oauth2.Token{
+		AccessToken: installationToken.Token,
+		TokenType:   "Bearer",
+		Expiry:      installationToken.ExpiresAt.Add(-githubAppTokenRefreshMargin),
+	}, nil
+}
+
+// createJwt signs app jwt with RS256, github only needs iat, exp and iss claims
+func (impl *GitHubAppTokenSource) createJwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-githubAppClockSkew).Unix(),
+		"exp": now.Add(githubAppJwtLifetime).Unix(),
+		"iss": impl.appId,
+	})
+	if err != nil {
+		return "", err
+	}
+	encoding := base64.RawURLEncoding
+	signingInput := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, impl.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + encoding.EncodeToString(signature), nil
+}
+
+// getGitHubAppPrivateKey reads PEM from GITHUB_APP_PRIVATE_KEY or else from GITHUB_APP_PRIVATE_KEY_PATH, github
+// issues PKCS#1 keys and PKCS#8 is accepted as well
+func (cfg *GitHubConfig) getGitHubAppPrivateKey() (*rsa.PrivateKey, error) {
+	pemContent := []byte(cfg.GitHubAppPrivateKey)
+	if len(bytes.TrimSpace(pemContent)) == 0 {
+		if len(cfg.GitHubAppPrivateKeyPath) == 0 {
+			return nil, errors.New("GITHUB_APP_PRIVATE_KEY or GITHUB_APP_PRIVATE_KEY_PATH is required with GITHUB_APP_ID")
+		}
+		content, err := os.ReadFile(cfg.GitHubAppPrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("error in reading github app private key, %v", err)
+		}
+		pemContent = content
+	}
+	block, _ := pem.Decode(pemContent)
+	if block == nil {
+		return nil, errors.New("github app private key is not PEM encoded")
+	}
+	if privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return privateKey, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github app private key, %v", err)
+	}
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github app private key is not an RSA key")
+	}
+	return privateKey, nil
+}
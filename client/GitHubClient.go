@@ -18,6 +18,8 @@ package util
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/caarlos0/env"
 	"github.com/google/go-github/github"
 	"go.uber.org/zap"
@@ -25,6 +27,9 @@ import (
 	http2 "net/http"
 	"net/url"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -40,6 +45,7 @@ const (
 	BITBUCKET_PROVIDER    = "BITBUCKET_CLOUD"
 	GITHUB_API_V3         = "api/v3"
 	GITHUB_HOST           = "github.com"
+	GITHUB_API_BASE_URL   = "https://api.github.com/"
 )
 
 type GitConfig struct {
@@ -62,16 +68,127 @@ type GitHubConfig struct {
 	GitHubOrg   string `env:"GITHUB_ORG" envDefault:""`
 	GitHubToken string `env:"GITHUB_TOKEN" envDefault:""`
 	GitHubRepo  string `env:"GITHUB_REPO" envDefault:"devtron"`
+	// GitHubRepos lists every repo whose releases are served, entries are repo names of GITHUB_ORG or owner/repo.
+	// First one is the primary repo served when no repo is requested, GITHUB_REPO is used when it is empty.
+	GitHubRepos []string `env:"GITHUB_REPOS" envDefault:"" envSeparator:","`
+
+	// GitHubAppId enables github app authentication in place of GITHUB_TOKEN, installation tokens are minted
+	// with the app private key and refreshed before they expire
+	GitHubAppId             int64 `env:"GITHUB_APP_ID" envDefault:"0"`
+	GitHubAppInstallationId int64 `env:"GITHUB_APP_INSTALLATION_ID" envDefault:"0"`
+	// GitHubAppPrivateKey is the PEM content, GitHubAppPrivateKeyPath is used when it is empty, e.g. a mounted secret
+	GitHubAppPrivateKey     string `env:"GITHUB_APP_PRIVATE_KEY" envDefault:""`
+	GitHubAppPrivateKeyPath string `env:"GITHUB_APP_PRIVATE_KEY_PATH" envDefault:""`
 
 	GitHubWebhookSecret   string `env:"GITHUB_WEBHOOK_SECRET" envDefault:""`
 	GitHubEventTypeHeader string `env:"GITHUB_EVENT_TYPE_HEADER" envDefault:"X-GitHub-Event"`
 	GitHubSecretHeader    string `env:"GITHUB_SECRET_HEADER" envDefault:"X-Hub-Signature"`
+	GitHubSecret256Header string `env:"GITHUB_SECRET_256_HEADER" envDefault:"X-Hub-Signature-256"`
+	GitHubDeliveryHeader  string `env:"GITHUB_DELIVERY_HEADER" envDefault:"X-GitHub-Delivery"`
+	// GitHubSubscribedEvents are the event types handled by webhook, other events are acknowledged and ignored
+	GitHubSubscribedEvents []string `env:"GITHUB_SUBSCRIBED_EVENTS" envDefault:"release" envSeparator:","`
+	// GitHubSecretValidator supported values SHA-1, SHA-256, auto, URL_APPEND, PLAIN_TEXT
 	GitHubSecretValidator string `env:"GITHUB_SECRET_VALIDATOR" envDefault:"SHA-1"`
+	// GitHubWebhookSecrets is a json list of {"id", "secret", "expiresAt"} used for rotation, first one is the
+	// current secret and the rest are previous ones accepted till they expire
+	GitHubWebhookSecrets string `env:"GITHUB_WEBHOOK_SECRETS" envDefault:""`
+
+	// GitHubReleasesPerPage is the page size used while listing releases, github allows at most 100
+	GitHubReleasesPerPage int `env:"GITHUB_RELEASES_PER_PAGE" envDefault:"100"`
+	// GitHubReleasesMaxCount caps the total number of releases fetched across pages, 0 means no cap
+	GitHubReleasesMaxCount int `env:"GITHUB_RELEASES_MAX_COUNT" envDefault:"1000"`
+
+	// GitHubRetryMaxAttempts is the number of tries of a github listing, waits grow exponentially with jitter
+	// from GitHubRetryInitialBackoff up to GitHubRetryMaxBackoff. Retry-After sent by github is honoured if it is
+	// not longer than max backoff, exhausted rate limit is never retried.
+	GitHubRetryMaxAttempts    int           `env:"GITHUB_RETRY_MAX_ATTEMPTS" envDefault:"3"`
+	GitHubRetryInitialBackoff time.Duration `env:"GITHUB_RETRY_INITIAL_BACKOFF" envDefault:"1s"`
+	GitHubRetryMaxBackoff     time.Duration `env:"GITHUB_RETRY_MAX_BACKOFF" envDefault:"30s"`
+	// GitHubETagCacheSize is the number of responses kept for conditional requests, 0 disables the cache
+	GitHubETagCacheSize int `env:"GITHUB_ETAG_CACHE_SIZE" envDefault:"32"`
+}
+
+type WebhookSecret struct {
+	KeyId     string     `json:"id"`
+	Secret    string     `json:"secret"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+const DefaultWebhookSecretKeyId = "default"
+
+// GetWebhookSecrets returns configured webhook secrets, current one first. GITHUB_WEBHOOK_SECRET is
+// kept for backward compatibility with key id default, it is the current secret when GITHUB_WEBHOOK_SECRETS
+// is empty and a previous one appended after the rotation list otherwise.
+func (cfg *GitHubConfig) GetWebhookSecrets() ([]*WebhookSecret, error) {
+	var webhookSecrets []*WebhookSecret
+	if len(strings.TrimSpace(cfg.GitHubWebhookSecrets)) > 0 {
+		err := json.Unmarshal([]byte(cfg.GitHubWebhookSecrets), &webhookSecrets)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_WEBHOOK_SECRETS, %v", err)
+		}
+	}
+	for i, webhookSecret := range webhookSecrets {
+		if webhookSecret == nil || len(webhookSecret.Secret) == 0 {
+			return nil, fmt.Errorf("invalid GITHUB_WEBHOOK_SECRETS, secret missing at index %d", i)
+		}
+		if len(webhookSecret.KeyId) == 0 {
+			webhookSecret.KeyId = strconv.Itoa(i)
+		}
+	}
+	if len(cfg.GitHubWebhookSecret) == 0 {
+		return webhookSecrets, nil
+	}
+	legacyWebhookSecret := &WebhookSecret{KeyId: DefaultWebhookSecretKeyId, Secret: cfg.GitHubWebhookSecret}
+	if len(webhookSecrets) == 0 {
+		// only secret configured is the current one, it must not be reported as deprecated
+		return []*WebhookSecret{legacyWebhookSecret}, nil
+	}
+	return append(webhookSecrets, legacyWebhookSecret), nil
+}
+
+// GetRepoFullNames returns owner/repo of configured repos without duplicates, primary repo first
+func (cfg *GitHubConfig) GetRepoFullNames() []string {
+	repos := cfg.GitHubRepos
+	if len(repos) == 0 {
+		repos = []string{cfg.GitHubRepo}
+	}
+	var fullNames []string
+	seen := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		repo = strings.Trim(strings.TrimSpace(repo), "/")
+		if len(repo) == 0 {
+			continue
+		}
+		if !strings.Contains(repo, "/") && len(cfg.GitHubOrg) > 0 {
+			repo = cfg.GitHubOrg + "/" + repo
+		}
+		if seen[strings.ToLower(repo)] {
+			continue
+		}
+		seen[strings.ToLower(repo)] = true
+		fullNames = append(fullNames, repo)
+	}
+	return fullNames
+}
+
+// SplitRepoFullName splits owner/repo, owner is empty if full name has no owner
+func SplitRepoFullName(fullName string) (owner string, repo string) {
+	index := strings.LastIndex(fullName, "/")
+	if index < 0 {
+		return "", fullName
+	}
+	return fullName[:index], fullName[index+1:]
+}
+
+// IsExpired returns true if secret has expiry and it is before now
+func (secret *WebhookSecret) IsExpired(now time.Time) bool {
+	return secret.ExpiresAt != nil && secret.ExpiresAt.Before(now)
 }
 
 type GitHubClient struct {
-	GitHubClient *github.Client
-	GitHubConfig *GitHubConfig
+	GitHubClient   *github.Client
+	GitHubConfig   *GitHubConfig
+	WebhookSecrets []*WebhookSecret
 }
 
 /* #nosec */
@@ -82,30 +199,59 @@ func NewGitHubClient(logger *zap.SugaredLogger) (*GitHubClient, error) {
 		logger.Error("err", err)
 		return &GitHubClient{}, err
 	}
-	ctx := context.Background()
-	httpTransport := &http2.Transport{}
-	httpClient := &http2.Client{Transport: httpTransport}
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: cfg.GitHubToken},
-	)
-	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
-	tc := oauth2.NewClient(ctx, ts)
-	var client *github.Client
+	webhookSecrets, err := cfg.GetWebhookSecrets()
+	if err != nil {
+		logger.Errorw("error in parsing webhook secrets", "err", err)
+		return &GitHubClient{}, err
+	}
 	hostUrl, err := url.Parse(cfg.GitHubHost)
 	if err != nil {
 		logger.Errorw("error in creating git client ", "host", hostUrl, "err", err)
 		return nil, err
 	}
-	if hostUrl.Host == GITHUB_HOST {
+	isEnterprise := hostUrl.Host != GITHUB_HOST
+	apiBaseUrl := GITHUB_API_BASE_URL
+	if isEnterprise {
+		hostUrl.Path = path.Join(hostUrl.Path, GITHUB_API_V3)
+		apiBaseUrl = hostUrl.String()
+	}
+	ctx := context.Background()
+	httpTransport := &http2.Transport{}
+	ts, err := getGitHubTokenSource(logger, cfg, apiBaseUrl, &http2.Client{Transport: httpTransport, Timeout: 30 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	// etag cache sits below oauth2 transport so that cached responses are only served to authorized requests
+	httpClient := &http2.Client{Transport: NewETagCacheTransport(httpTransport, cfg.GitHubETagCacheSize)}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	tc := oauth2.NewClient(ctx, ts)
+	var client *github.Client
+	if !isEnterprise {
 		client = github.NewClient(tc)
 	} else {
 		logger.Infow("creating github EnterpriseClient with org", "host", cfg.GitHubHost, "org", cfg.GitHubOrg)
-		hostUrl.Path = path.Join(hostUrl.Path, GITHUB_API_V3)
-		client, err = github.NewEnterpriseClient(hostUrl.String(), hostUrl.String(), tc)
+		client, err = github.NewEnterpriseClient(apiBaseUrl, apiBaseUrl, tc)
 	}
 	gitHubClient := &GitHubClient{
-		GitHubClient: client,
-		GitHubConfig: cfg,
+		GitHubClient:   client,
+		GitHubConfig:   cfg,
+		WebhookSecrets: webhookSecrets,
 	}
 	return gitHubClient, err
 }
+
+// getGitHubTokenSource returns github app installation token source when GITHUB_APP_ID is set and GITHUB_TOKEN otherwise
+func getGitHubTokenSource(logger *zap.SugaredLogger, cfg *GitHubConfig, apiBaseUrl string, httpClient *http2.Client) (oauth2.TokenSource, error) {
+	if cfg.GitHubAppId <= 0 {
+		return oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: cfg.GitHubToken},
+		), nil
+	}
+	tokenSource, err := NewGitHubAppTokenSource(cfg, apiBaseUrl, httpClient)
+	if err != nil {
+		logger.Errorw("error in configuring github app authentication", "appId", cfg.GitHubAppId, "err", err)
+		return nil, err
+	}
+	logger.Infow("using github app authentication", "appId", cfg.GitHubAppId, "installationId", cfg.GitHubAppInstallationId)
+	return tokenSource, nil
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"github.com/google/go-github/github"
+	"math/rand"
+	http2 "net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// jitterRandom is seeded per process, default source of math/rand gives every replica the same sequence
+var jitterRandom = rand.New(rand.NewSource(time.Now().UnixNano()))
+var jitterLock sync.Mutex
+
+// HttpStatusError is returned by release sources not using go-github for non 2xx responses
+type HttpStatusError struct {
+	StatusCode int
+	Header     http2.Header
+	Url        string
+}
+
+func (e *HttpStatusError) Error() string {
+	return fmt.Sprintf("request to %s failed with status %d", e.Url, e.StatusCode)
+}
+
+// GetRetryDelay returns how long to wait before attempt+1 of a failed release source call, false when it must
+// not be retried. attempt starts from 1. Retry settings of GitHubConfig apply to every release source.
+func (impl *GitHubClient) GetRetryDelay(err error, attempt int) (time.Duration, bool) {
+	cfg := impl.GitHubConfig
+	if attempt >= cfg.GitHubRetryMaxAttempts {
+		return 0, false
+	}
+	switch typedErr := err.(type) {
+	case *github.RateLimitError:
+		// quota is exhausted till reset, retrying only burns time
+		return 0, false
+	case *github.AbuseRateLimitError:
+		if typedErr.RetryAfter != nil {
+			return impl.getRetryAfterDelay(*typedErr.RetryAfter)
+		}
+	case *github.ErrorResponse:
+		if typedErr.Response != nil {
+			return impl.getStatusRetryDelay(typedErr.Response.StatusCode, typedErr.Response.Header, attempt)
+		}
+	case *HttpStatusError:
+		return impl.getStatusRetryDelay(typedErr.StatusCode, typedErr.Header, attempt)
+	}
+	return impl.getBackoff(attempt), true
+}
+
+func (impl *GitHubClient) getStatusRetryDelay(statusCode int, header http2.Header, attempt int) (time.Duration, bool) {
+	if retryAfter, ok := parseRetryAfter(header); ok {
+		return impl.getRetryAfterDelay(retryAfter)
+	}
+	if statusCode != http2.StatusTooManyRequests && statusCode < http2.StatusInternalServerError {
+		// not found, bad credentials and validation errors do not change on retry
+		return 0, false
+	}
+	return impl.getBackoff(attempt), true
+}
+
+// getRetryAfterDelay honours Retry-After sent by github, waits longer than max backoff are not retried
+func (impl *GitHubClient) getRetryAfterDelay(retryAfter time.Duration) (time.Duration, bool) {
+	if retryAfter > impl.GitHubConfig.GitHubRetryMaxBackoff {
+		return 0, false
+	}
+	return retryAfter, true
+}
+
+// getBackoff doubles initial backoff per attempt up to max backoff and picks a random wait in its upper half
+func (impl *GitHubClient) getBackoff(attempt int) time.Duration {
+	cfg := impl.GitHubConfig
+	backoff := cfg.GitHubRetryInitialBackoff
+	for i := 1; i < attempt && backoff < cfg.GitHubRetryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > cfg.GitHubRetryMaxBackoff {
+		backoff = cfg.GitHubRetryMaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	jitterLock.Lock()
+	defer jitterLock.Unlock()
+	return backoff/2 + time.Duration(jitterRandom.Int63n(int64(backoff/2)+1))
+}
+
+func parseRetryAfter(header http2.Header) (time.Duration, bool) {
+	retryAfter := header.Get("Retry-After")
+	if len(retryAfter) == 0 {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(retryAfter)
+	if err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	retryAt, err := http2.ParseTime(retryAfter)
+	if err != nil {
+		return 0, false
+	}
+	delay := time.Until(retryAt)
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"bytes"
+	"container/list"
+	"github.com/devtron-labs/central-api/internal/metrics"
+	"io"
+	http2 "net/http"
+	"strconv"
+	"sync"
+)
+
+const (
+	headerETag               = "ETag"
+	headerIfNoneMatch        = "If-None-Match"
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemain    = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+	headerRateLimitResource  = "X-RateLimit-Resource"
+	defaultRateLimitResource = "core"
+)
+
+var githubRequests = metrics.NewCounterVec("central_api_github_requests_total",
+	"requests sent to github api by response status code, 304 is a conditional request served from etag cache", "code")
+var githubRateLimitRemaining = metrics.NewGaugeVec("central_api_github_rate_limit_remaining",
+	"requests left in current github rate limit window", "resource")
+var githubRateLimitLimit = metrics.NewGaugeVec("central_api_github_rate_limit_limit",
+	"requests allowed per github rate limit window", "resource")
+var githubRateLimitReset = metrics.NewGaugeVec("central_api_github_rate_limit_reset_timestamp_seconds",
+	"unix time at which current github rate limit window resets", "resource")
+
+type etagCacheEntry struct {
+	key    string
+	etag   string
+	header http2.Header
+	body   []byte
+}
+
+// ETagCacheTransport sends If-None-Match for GET requests it has a cached response of. GitHub answers 304 without
+// body when nothing changed and does not count it against the rate limit, the cached response is then returned
+// as 200 so that callers can not tell the difference. Rate limit headers of every response are exported as metrics.
+type ETagCacheTransport struct {
+	Base    http2.RoundTripper
+	maxSize int
+	mutex   sync.Mutex
+	// entries are ordered least recently used first
+	entries *list.List
+	index   map[string]*list.Element
+}
+
+func NewETagCacheTransport(base http2.RoundTripper, maxSize int) *ETagCacheTransport {
+	return &ETagCacheTransport{
+		Base:    base,
+		maxSize: maxSize,
+		entries: list.New(),
+		index:   make(map[string]*list.Element),
+	}
+}
+
+func (impl *ETagCacheTransport) RoundTrip(req *http2.Request) (*http2.Response, error) {
+	if req.Method != http2.MethodGet || impl.maxSize <= 0 {
+		return impl.roundTrip(req)
+	}
+	key := req.Header.Get("Accept") + " " + req.URL.String()
+	cached := impl.get(key)
+	if cached != nil {
+		// request passed to RoundTrip must not be modified
+		req = req.Clone(req.Context())
+		req.Header.Set(headerIfNoneMatch, cached.etag)
+	}
+	resp, err := impl.roundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if resp.StatusCode == http2.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		return cached.toResponse(req, resp.Header), nil
+	}
+	etag := resp.Header.Get(headerETag)
+	if resp.StatusCode != http2.StatusOK || len(etag) == 0 {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	impl.put(&etagCacheEntry{key: key, etag: etag, header: resp.Header.Clone(), body: body})
+	return resp, nil
+}
+
+func (impl *ETagCacheTransport) roundTrip(req *http2.Request) (*http2.Response, error) {
+	resp, err := impl.Base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	githubRequests.Inc(strconv.Itoa(resp.StatusCode))
+	recordRateLimit(resp.Header)
+	return resp, nil
+}
+
+func (impl *ETagCacheTransport) get(key string) *etagCacheEntry {
+	impl.mutex.Lock()
+	defer impl.mutex.Unlock()
+	element, ok := impl.index[key]
+	if !ok {
+		return nil
+	}
+	impl.entries.MoveToBack(element)
+	return element.Value.(*etagCacheEntry)
+}
+
+func (impl *ETagCacheTransport) put(entry *etagCacheEntry) {
+	impl.mutex.Lock()
+	defer impl.mutex.Unlock()
+	if element, ok := impl.index[entry.key]; ok {
+		element.Value = entry
+		impl.entries.MoveToBack(element)
+		return
+	}
+	impl.index[entry.key] = impl.entries.PushBack(entry)
+	for impl.entries.Len() > impl.maxSize {
+		oldest := impl.entries.Front()
+		impl.entries.Remove(oldest)
+		delete(impl.index, oldest.Value.(*etagCacheEntry).key)
+	}
+}
+
+// toResponse builds 200 response from cache, headers of 304 response win since they carry current rate limit
+func (entry *etagCacheEntry) toResponse(req *http2.Request, notModifiedHeader http2.Header) *http2.Response {
+	header := entry.header.Clone()
+	for name, values := range notModifiedHeader {
+		if name == "Content-Length" {
+			continue
+		}
+		header[name] = values
+	}
+	return &http2.Response{
+		Status:        "200 OK",
+		StatusCode:    http2.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       req,
+	}
+}
+
+func recordRateLimit(header http2.Header) {
+	remaining := header.Get(headerRateLimitRemain)
+	if len(remaining) == 0 {
+		return
+	}
+	resource := header.Get(headerRateLimitResource)
+	if len(resource) == 0 {
+		resource = defaultRateLimitResource
+	}
+	if value, err := strconv.ParseFloat(remaining, 64); err == nil {
+		githubRateLimitRemaining.Set(value, resource)
+	}
+	if value, err := strconv.ParseFloat(header.Get(headerRateLimitLimit), 64); err == nil {
+		githubRateLimitLimit.Set(value, resource)
+	}
+	if value, err := strconv.ParseFloat(header.Get(headerRateLimitReset), 64); err == nil {
+		githubRateLimitReset.Set(value, resource)
+	}
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"github.com/caarlos0/env"
+	"go.uber.org/zap"
+	"time"
+)
+
+type ReconcileConfig struct {
+	// Enabled turns periodic resync of releases from github on, webhooks keep working when it is off
+	Enabled bool `env:"RECONCILE_ENABLED" envDefault:"true"`
+	// Interval is the minimum time between two runs, a random delay up to Jitter is added to every wait
+	// so that replicas started together do not hit github at the same time
+	Interval time.Duration `env:"RECONCILE_INTERVAL" envDefault:"15m"`
+	Jitter   time.Duration `env:"RECONCILE_JITTER" envDefault:"1m"`
+	// LockTTL is how long a run holds the lock in blob storage, it has to be longer than a run takes
+	LockTTL time.Duration `env:"RECONCILE_LOCK_TTL" envDefault:"10m"`
+}
+
+func NewReconcileConfig(logger *zap.SugaredLogger) (*ReconcileConfig, error) {
+	cfg := &ReconcileConfig{}
+	err := env.Parse(cfg)
+	if err != nil {
+		logger.Errorw("error on parsing reconcile config", "err", err)
+		return &ReconcileConfig{}, err
+	}
+	return cfg, nil
+}
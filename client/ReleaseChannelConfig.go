@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"github.com/caarlos0/env"
+	"go.uber.org/zap"
+	"regexp"
+	"strings"
+)
+
+var releaseChannelNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+type ReleaseChannelConfig struct {
+	// Channels are ordered most stable first, feed of a channel serves its releases and releases of channels
+	// before it. Releases without pre-release suffix and prerelease flag belong to the first channel.
+	Channels []string `env:"RELEASE_CHANNELS" envDefault:"stable,rc,beta" envSeparator:","`
+	// Rules map pre-release suffix to channel, entries are channel=prefix|prefix. First identifier of the suffix
+	// is matched by prefix case insensitively, e.g. rc matches v0.7.0-rc.1 and v0.7.0-rc1.
+	Rules []string `env:"RELEASE_CHANNEL_RULES" envDefault:"rc=rc,beta=beta|alpha" envSeparator:","`
+	// PrereleaseChannel is used for releases marked prerelease and for suffixes no rule matches
+	PrereleaseChannel string `env:"RELEASE_CHANNEL_PRERELEASE" envDefault:"beta"`
+	// DefaultChannel is served when channel is not requested
+	DefaultChannel string `env:"RELEASE_CHANNEL_DEFAULT" envDefault:"stable"`
+}
+
+// ReleaseChannelRule maps pre-release suffix prefixes to channel
+type ReleaseChannelRule struct {
+	Channel  string
+	Prefixes []string
+}
+
+func NewReleaseChannelConfig(logger *zap.SugaredLogger) (*ReleaseChannelConfig, error) {
+	cfg := &ReleaseChannelConfig{}
+	err := env.Parse(cfg)
+	if err != nil {
+		logger.Errorw("error on parsing release channel config", "err", err)
+		return &ReleaseChannelConfig{}, err
+	}
+	_, err = cfg.GetRules()
+	if err != nil {
+		logger.Errorw("invalid release channel config", "err", err)
+		return &ReleaseChannelConfig{}, err
+	}
+	return cfg, nil
+}
+
+// GetRules validates channels and returns parsed rules in configured order
+func (cfg *ReleaseChannelConfig) GetRules() ([]*ReleaseChannelRule, error) {
+	if len(cfg.Channels) == 0 {
+		return nil, fmt.Errorf("RELEASE_CHANNELS is empty")
+	}
+	channels := make(map[string]bool, len(cfg.Channels))
+	for _, channel := range cfg.Channels {
+		// channel is part of blob keys of latest tag pointers
+		if !releaseChannelNameRegex.MatchString(channel) {
+			return nil, fmt.Errorf("invalid channel %q in RELEASE_CHANNELS, lower case letters, digits and - are allowed", channel)
+		}
+		if channels[channel] {
+			return nil, fmt.Errorf("duplicate channel %s in RELEASE_CHANNELS", channel)
+		}
+		channels[channel] = true
+	}
+	if !channels[cfg.PrereleaseChannel] {
+		return nil, fmt.Errorf("RELEASE_CHANNEL_PRERELEASE %s is not one of RELEASE_CHANNELS", cfg.PrereleaseChannel)
+	}
+	if !channels[cfg.DefaultChannel] {
+		return nil, fmt.Errorf("RELEASE_CHANNEL_DEFAULT %s is not one of RELEASE_CHANNELS", cfg.DefaultChannel)
+	}
+	var rules []*ReleaseChannelRule
+	for _, entry := range cfg.Rules {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		channel, prefixes, found := strings.Cut(entry, "=")
+		channel = strings.TrimSpace(channel)
+		if !found || !channels[channel] {
+			return nil, fmt.Errorf("invalid RELEASE_CHANNEL_RULES entry %q, expected channel=prefix|prefix with a channel of RELEASE_CHANNELS", entry)
+		}
+		rule := &ReleaseChannelRule{Channel: channel}
+		for _, prefix := range strings.Split(prefixes, "|") {
+			prefix = strings.ToLower(strings.TrimSpace(prefix))
+			if len(prefix) > 0 {
+				rule.Prefixes = append(rule.Prefixes, prefix)
+			}
+		}
+		if len(rule.Prefixes) == 0 {
+			return nil, fmt.Errorf("invalid RELEASE_CHANNEL_RULES entry %q, no prefix", entry)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"github.com/caarlos0/env"
+	"go.uber.org/zap"
+)
+
+const GITEA_PROVIDER = "GITEA"
+
+type ReleaseSourceConfig struct {
+	// Provider is where releases are read from and webhooks come from, supported values GITHUB, GITLAB, GITEA.
+	// GitHub is configured through GitHubConfig.
+	Provider string `env:"RELEASE_SOURCE_PROVIDER" envDefault:"GITHUB"`
+	// ReleasesMaxCount caps the total number of releases fetched across pages by GitLab and Gitea, 0 means no cap.
+	// GitHub is capped by GitHubReleasesMaxCount.
+	ReleasesMaxCount int `env:"RELEASES_MAX_COUNT" envDefault:"1000"`
+
+	GitLabHost  string `env:"GITLAB_HOST" envDefault:"https://gitlab.com"`
+	GitLabToken string `env:"GITLAB_TOKEN" envDefault:""`
+	// GitLabProject is numeric id or full path of the project, e.g. devtron-labs/devtron
+	GitLabProject string `env:"GITLAB_PROJECT" envDefault:""`
+	// GitLabWebhookToken is the secret token of the webhook, gitlab sends it as is in X-Gitlab-Token header
+	GitLabWebhookToken string `env:"GITLAB_WEBHOOK_TOKEN" envDefault:""`
+
+	GiteaHost  string `env:"GITEA_HOST" envDefault:""`
+	GiteaToken string `env:"GITEA_TOKEN" envDefault:""`
+	GiteaOwner string `env:"GITEA_OWNER" envDefault:""`
+	GiteaRepo  string `env:"GITEA_REPO" envDefault:"devtron"`
+	// GiteaWebhookSecret signs payloads, gitea sends hex HMAC-SHA256 of body in X-Gitea-Signature header
+	GiteaWebhookSecret string `env:"GITEA_WEBHOOK_SECRET" envDefault:""`
+}
+
+func NewReleaseSourceConfig(logger *zap.SugaredLogger) (*ReleaseSourceConfig, error) {
+	cfg := &ReleaseSourceConfig{}
+	err := env.Parse(cfg)
+	if err != nil {
+		logger.Errorw("error on parsing release source config", "err", err)
+		return &ReleaseSourceConfig{}, err
+	}
+	switch cfg.Provider {
+	case GITHUB_PROVIDER:
+	case GITLAB_PROVIDER:
+		if len(cfg.GitLabProject) == 0 {
+			return &ReleaseSourceConfig{}, fmt.Errorf("GITLAB_PROJECT is required for %s release source", cfg.Provider)
+		}
+	case GITEA_PROVIDER:
+		if len(cfg.GiteaHost) == 0 || len(cfg.GiteaOwner) == 0 {
+			return &ReleaseSourceConfig{}, fmt.Errorf("GITEA_HOST and GITEA_OWNER are required for %s release source", cfg.Provider)
+		}
+	default:
+		return &ReleaseSourceConfig{}, fmt.Errorf("unsupported release source provider %s", cfg.Provider)
+	}
+	logger.Infow("release source selected", "provider", cfg.Provider)
+	return cfg, nil
+}
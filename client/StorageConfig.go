@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"github.com/caarlos0/env"
+	"go.uber.org/zap"
+)
+
+const (
+	STORAGE_BACKEND_POSTGRES   = "postgres"
+	STORAGE_BACKEND_BLOB       = "blob"
+	STORAGE_BACKEND_FILESYSTEM = "filesystem"
+	STORAGE_BACKEND_MEMORY     = "memory"
+)
+
+type StorageConfig struct {
+	// Backend supported values postgres, blob, filesystem, memory. When empty blob is used if CLOUD_CONFIGURED is set
+	// and postgres otherwise
+	Backend string `env:"STORAGE_BACKEND" envDefault:""`
+	// FileSystemDir keeps releases, latest tag and webhook history for filesystem backend, can be a mounted volume
+	FileSystemDir string `env:"STORAGE_FILESYSTEM_DIR" envDefault:"/var/lib/central-api"`
+}
+
+func NewStorageConfig(logger *zap.SugaredLogger, blobConfig *BlobConfigVariables) (*StorageConfig, error) {
+	cfg := &StorageConfig{}
+	err := env.Parse(cfg)
+	if err != nil {
+		logger.Errorw("error on parsing storage config", "err", err)
+		return &StorageConfig{}, err
+	}
+	switch cfg.Backend {
+	case "":
+		cfg.Backend = STORAGE_BACKEND_POSTGRES
+		if blobConfig.CloudConfigured {
+			cfg.Backend = STORAGE_BACKEND_BLOB
+		}
+	case STORAGE_BACKEND_POSTGRES, STORAGE_BACKEND_BLOB, STORAGE_BACKEND_FILESYSTEM, STORAGE_BACKEND_MEMORY:
+	default:
+		return &StorageConfig{}, fmt.Errorf("unsupported storage backend %s", cfg.Backend)
+	}
+	logger.Infow("storage backend selected", "backend", cfg.Backend)
+	return cfg, nil
+}
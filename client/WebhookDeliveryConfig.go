@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"github.com/caarlos0/env"
+	"go.uber.org/zap"
+	"time"
+)
+
+type WebhookDeliveryConfig struct {
+	// InMemoryLogSize is the number of deliveries kept when no database is configured
+	InMemoryLogSize int `env:"WEBHOOK_DELIVERY_LOG_SIZE" envDefault:"500"`
+	// AdminToken protects delivery list and replay endpoints, they are disabled when it is empty
+	AdminToken string `env:"ADMIN_API_TOKEN" envDefault:""`
+
+	// WorkerCount is the number of workers applying queued deliveries, deliveries of a release always go to the same worker
+	WorkerCount int `env:"WEBHOOK_WORKER_COUNT" envDefault:"2"`
+	QueueSize   int `env:"WEBHOOK_QUEUE_SIZE" envDefault:"100"`
+	// MaxAttempts is the number of tries after which a delivery is moved to dead letter
+	MaxAttempts         int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"5"`
+	RetryInitialBackoff time.Duration `env:"WEBHOOK_RETRY_INITIAL_BACKOFF" envDefault:"1s"`
+	RetryMaxBackoff     time.Duration `env:"WEBHOOK_RETRY_MAX_BACKOFF" envDefault:"1m"`
+	// DrainTimeout is how long shutdown waits for queued deliveries, undrained ones are picked again on startup
+	DrainTimeout time.Duration `env:"WEBHOOK_DRAIN_TIMEOUT" envDefault:"30s"`
+	// StaleDeliveryTimeout is the age after which a received or queued delivery is treated as lost by a crash,
+	// redelivery of such a delivery is processed again instead of being skipped as duplicate
+	StaleDeliveryTimeout time.Duration `env:"WEBHOOK_STALE_DELIVERY_TIMEOUT" envDefault:"10m"`
+}
+
+func NewWebhookDeliveryConfig(logger *zap.SugaredLogger) (*WebhookDeliveryConfig, error) {
+	cfg := &WebhookDeliveryConfig{}
+	err := env.Parse(cfg)
+	if err != nil {
+		logger.Errorw("error on parsing webhook delivery config", "err", err)
+		return &WebhookDeliveryConfig{}, err
+	}
+	return cfg, nil
+}
@@ -32,6 +32,12 @@ type ApiError struct {
 	UserDetailMessage string      `json:"userDetailMessage,omitempty"`
 }
 
+// FieldError describes a single invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 type ReleaseList struct {
 	Releases []*Release `json:"releases"`
 }
@@ -45,6 +51,115 @@ type Release struct {
 	Prerequisite        bool      `json:"prerequisite"`
 	PrerequisiteMessage string    `json:"prerequisiteMessage"`
 	TagLink             string    `json:"tagLink"`
+	Prerelease          bool      `json:"prerelease"`
+	Draft               bool      `json:"draft"`
+	// Repo is owner/repo full name of the repo release belongs to
+	Repo string `json:"repo,omitempty"`
+	// Channel is set while serving, it is derived from tag and prerelease flag by configured rules
+	Channel string `json:"channel,omitempty"`
+	// Sections are parsed from Body on ingestion, exposed only in ReleaseV2
+	Sections []*ReleaseSection `json:"-"`
+}
+
+// ReleaseV2 is the response shape of /v2/release/notes, raw body is kept alongside parsed sections
+type ReleaseV2 struct {
+	TagName      string                 `json:"tagName"`
+	ReleaseName  string                 `json:"releaseName"`
+	CreatedAt    time.Time              `json:"createdAt"`
+	PublishedAt  time.Time              `json:"publishedAt"`
+	TagLink      string                 `json:"tagLink"`
+	Repo         string                 `json:"repo,omitempty"`
+	Channel      string                 `json:"channel,omitempty"`
+	Prerequisite *ReleasePrerequisiteV2 `json:"prerequisite"`
+	Notes        *ReleaseNotesV2        `json:"notes"`
+}
+
+type ReleasePrerequisiteV2 struct {
+	Required bool   `json:"required"`
+	Message  string `json:"message"`
+}
+
+type ReleaseNotesV2 struct {
+	Body     string            `json:"body"`
+	Sections []*ReleaseSection `json:"sections"`
+}
+
+type ReleaseSectionType string
+
+const (
+	ReleaseSectionNewFeatures     ReleaseSectionType = "NEW_FEATURES"
+	ReleaseSectionEnhancements    ReleaseSectionType = "ENHANCEMENTS"
+	ReleaseSectionBugFixes        ReleaseSectionType = "BUG_FIXES"
+	ReleaseSectionBreakingChanges ReleaseSectionType = "BREAKING_CHANGES"
+	ReleaseSectionDocumentation   ReleaseSectionType = "DOCUMENTATION"
+	ReleaseSectionOthers          ReleaseSectionType = "OTHERS"
+)
+
+type ReleaseSection struct {
+	Type  ReleaseSectionType    `json:"type"`
+	Title string                `json:"title"`
+	Items []*ReleaseSectionItem `json:"items"`
+}
+
+type ReleaseSectionItem struct {
+	Text      string   `json:"text"`
+	PrNumbers []int    `json:"prNumbers,omitempty"`
+	Authors   []string `json:"authors,omitempty"`
+}
+
+// ReleaseRange is the changelog between two versions, releases are ordered latest first
+// and prerequisites are ordered in the sequence they need to be applied while upgrading
+type ReleaseRange struct {
+	From          string                 `json:"from"`
+	To            string                 `json:"to"`
+	Releases      []*Release             `json:"releases"`
+	Prerequisites []*ReleasePrerequisite `json:"prerequisites"`
+}
+
+type ReleasePrerequisite struct {
+	TagName             string `json:"tagName"`
+	PrerequisiteMessage string `json:"prerequisiteMessage"`
+	TagLink             string `json:"tagLink"`
+}
+
+// UpgradePath is the ordered list of hops an install has to go through to reach target version,
+// DirectUpgradeSafe is false when a release with prerequisites lies between current and target
+type UpgradePath struct {
+	Current           string        `json:"current"`
+	Target            string        `json:"target"`
+	DirectUpgradeSafe bool          `json:"directUpgradeSafe"`
+	Hops              []*UpgradeHop `json:"hops"`
+}
+
+type UpgradeHop struct {
+	From                string `json:"from"`
+	To                  string `json:"to"`
+	Prerequisite        bool   `json:"prerequisite"`
+	PrerequisiteMessage string `json:"prerequisiteMessage"`
+	TagLink             string `json:"tagLink"`
+}
+
+// WebhookDelivery is a received webhook as stored in delivery log, keyed by github delivery id
+type WebhookDelivery struct {
+	DeliveryId     string              `json:"deliveryId"`
+	EventType      string              `json:"eventType"`
+	Headers        map[string][]string `json:"headers"`
+	Body           string              `json:"body"`
+	SignatureValid bool                `json:"signatureValid"`
+	Status         string              `json:"status"`
+	Error          string              `json:"error,omitempty"`
+	ReplayCount    int                 `json:"replayCount"`
+	Attempts       int                 `json:"attempts"`
+	ReceivedOn     time.Time           `json:"receivedOn"`
+	ProcessedOn    *time.Time          `json:"processedOn,omitempty"`
+}
+
+// WebhookPingResponse is the reply to github ping event sent on webhook creation
+type WebhookPingResponse struct {
+	HookId           int64    `json:"hookId"`
+	Zen              string   `json:"zen,omitempty"`
+	HookEvents       []string `json:"hookEvents"`
+	SubscribedEvents []string `json:"subscribedEvents"`
 }
 
 const MODULE_CICD = "cicd"
@@ -0,0 +1,231 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// linkDestinationPattern allows one level of balanced parentheses in link and image urls,
+// e.g. https://en.wikipedia.org/wiki/Go_(programming_language)
+const linkDestinationPattern = `(?:[^()\s]|\([^()\s]*\))+`
+
+var (
+	fenceRegex          = regexp.MustCompile("^(```|~~~)")
+	headingLineRegex    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	ruleRegex           = regexp.MustCompile(`^(?:-\s*){3,}$|^(?:\*\s*){3,}$|^(?:_\s*){3,}$`)
+	listLineRegex       = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	blockHtmlRegex      = regexp.MustCompile(`^</?(?:details|summary|table|thead|tbody|tr|td|th|div|p|ul|ol|li|pre|blockquote|h[1-6])\b`)
+	inlineHtmlRegex     = regexp.MustCompile(`<!--[\s\S]*?-->|</?[a-zA-Z][a-zA-Z0-9]*(?:\s[^<>]*)?/?>`)
+	codeSpanRegex       = regexp.MustCompile("`+([^`]+?)`+")
+	imageRegex          = regexp.MustCompile(`!\[([^\]]*)\]\(\s*(` + linkDestinationPattern + `)(?:\s+"[^"]*")?\s*\)`)
+	linkRegex           = regexp.MustCompile(`\[([^\]]+)\]\(\s*(` + linkDestinationPattern + `)(?:\s+"[^"]*")?\s*\)`)
+	autoLinkRegex       = regexp.MustCompile(`https?://[^\s<>()\x00]*[^\s<>()\x00.,;:!?'"]`)
+	strongRegex         = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	emphasisRegex       = regexp.MustCompile(`(^|[^*\w])\*(\S(?:[^*]*?\S)?)\*|(^|[^_\w])_(\S(?:[^_]*?\S)?)_`)
+	strikeRegex         = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	issueReferenceRegex = regexp.MustCompile(`(^|[\s(\[])#(\d+)\b`)
+	placeholderRegex    = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// Renderer renders github flavoured release markdown, only the subset used in release notes is supported:
+// headings, lists, code, block quotes, rules, emphasis, links, images and inline html
+type Renderer struct {
+	// IssueBaseUrl is used to link issue references like #1234, references are not linked when empty
+	IssueBaseUrl string
+}
+
+func NewRenderer(issueBaseUrl string) *Renderer {
+	return &Renderer{IssueBaseUrl: strings.TrimSuffix(issueBaseUrl, "/")}
+}
+
+// RenderHTML converts markdown to sanitized html
+func (impl *Renderer) RenderHTML(markdown string) string {
+	var builder strings.Builder
+	impl.renderBlocks(&builder, splitLines(removeNulBytes(markdown)))
+	return Sanitize(builder.String())
+}
+
+type listState struct {
+	indent  int
+	ordered bool
+}
+
+func (impl *Renderer) renderBlocks(builder *strings.Builder, lines []string) {
+	var paragraph []string
+	var lists []listState
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			builder.WriteString("<p>" + impl.renderInline(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+	closeLists := func(indent int) {
+		for len(lists) > 0 && lists[len(lists)-1].indent >= indent {
+			if lists[len(lists)-1].ordered {
+				builder.WriteString("</li></ol>\n")
+			} else {
+				builder.WriteString("</li></ul>\n")
+			}
+			lists = lists[:len(lists)-1]
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if fence := fenceRegex.FindString(trimmed); len(fence) > 0 {
+			flushParagraph()
+			closeLists(0)
+			var code []string
+			for i = i + 1; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			builder.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+			continue
+		}
+		if len(trimmed) == 0 {
+			flushParagraph()
+			continue
+		}
+		if match := listLineRegex.FindStringSubmatch(line); match != nil && !ruleRegex.MatchString(trimmed) {
+			flushParagraph()
+			indent := len(strings.Replace(match[1], "\t", "    ", -1))
+			ordered := !strings.ContainsAny(match[2], "-*+")
+			if len(lists) > 0 && lists[len(lists)-1].indent > indent {
+				closeLists(indent + 1)
+			}
+			if len(lists) > 0 && lists[len(lists)-1].indent == indent && lists[len(lists)-1].ordered != ordered {
+				closeLists(indent)
+			}
+			if len(lists) == 0 || lists[len(lists)-1].indent < indent {
+				lists = append(lists, listState{indent: indent, ordered: ordered})
+				if ordered {
+					builder.WriteString("<ol>\n<li>")
+				} else {
+					builder.WriteString("<ul>\n<li>")
+				}
+			} else {
+				builder.WriteString("</li>\n<li>")
+			}
+			builder.WriteString(impl.renderInline(match[3]))
+			continue
+		}
+		if len(lists) > 0 && line != trimmed && len(paragraph) == 0 {
+			// indented line continues the current list item
+			builder.WriteString(" " + impl.renderInline(trimmed))
+			continue
+		}
+		closeLists(0)
+		if match := headingLineRegex.FindStringSubmatch(trimmed); match != nil {
+			flushParagraph()
+			level := len(match[1])
+			builder.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", level, impl.renderInline(match[2]), level))
+			continue
+		}
+		if ruleRegex.MatchString(trimmed) {
+			flushParagraph()
+			builder.WriteString("<hr>\n")
+			continue
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			flushParagraph()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			builder.WriteString("<blockquote>\n")
+			impl.renderBlocks(builder, quoted)
+			builder.WriteString("</blockquote>\n")
+			continue
+		}
+		if blockHtmlRegex.MatchString(trimmed) || (strings.HasPrefix(trimmed, "<!--") && strings.HasSuffix(trimmed, "-->")) {
+			flushParagraph()
+			builder.WriteString(impl.renderInline(trimmed) + "\n")
+			continue
+		}
+		paragraph = append(paragraph, trimmed)
+	}
+	flushParagraph()
+	closeLists(0)
+}
+
+// renderInline renders inline markdown, generated markup is kept in placeholders so that
+// later replacements and escaping never touch it
+func (impl *Renderer) renderInline(text string) string {
+	var fragments []string
+	hold := func(fragment string) string {
+		fragments = append(fragments, fragment)
+		return fmt.Sprintf("\x00%d\x00", len(fragments)-1)
+	}
+	text = codeSpanRegex.ReplaceAllStringFunc(text, func(match string) string {
+		return hold("<code>" + html.EscapeString(codeSpanRegex.FindStringSubmatch(match)[1]) + "</code>")
+	})
+	text = inlineHtmlRegex.ReplaceAllStringFunc(text, hold)
+	text = imageRegex.ReplaceAllStringFunc(text, func(match string) string {
+		groups := imageRegex.FindStringSubmatch(match)
+		return hold(fmt.Sprintf(`<img src="%s" alt="%s">`, html.EscapeString(groups[2]), html.EscapeString(groups[1])))
+	})
+	text = linkRegex.ReplaceAllStringFunc(text, func(match string) string {
+		groups := linkRegex.FindStringSubmatch(match)
+		return hold(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(groups[2]), impl.renderEmphasis(html.EscapeString(groups[1]))))
+	})
+	text = autoLinkRegex.ReplaceAllStringFunc(text, func(match string) string {
+		return hold(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(match), html.EscapeString(match)))
+	})
+	if len(impl.IssueBaseUrl) > 0 {
+		text = issueReferenceRegex.ReplaceAllStringFunc(text, func(match string) string {
+			groups := issueReferenceRegex.FindStringSubmatch(match)
+			return groups[1] + hold(fmt.Sprintf(`<a href="%s/%s">#%s</a>`, impl.IssueBaseUrl, groups[2], groups[2]))
+		})
+	}
+	text = impl.renderEmphasis(html.EscapeString(text))
+	text = strings.ReplaceAll(text, "\n", "<br>\n")
+	return restorePlaceholders(text, fragments)
+}
+
+// restorePlaceholders replaces placeholders with their fragments. A fragment can hold placeholders of fragments
+// held before it, e.g. code span inside link text, so only those are expanded in it and expansion always ends.
+func restorePlaceholders(text string, fragments []string) string {
+	return placeholderRegex.ReplaceAllStringFunc(text, func(match string) string {
+		index, err := strconv.Atoi(placeholderRegex.FindStringSubmatch(match)[1])
+		if err != nil || index >= len(fragments) {
+			return ""
+		}
+		return restorePlaceholders(fragments[index], fragments[:index])
+	})
+}
+
+func (impl *Renderer) renderEmphasis(text string) string {
+	text = strongRegex.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = emphasisRegex.ReplaceAllString(text, "$1$3<em>$2$4</em>")
+	text = strikeRegex.ReplaceAllString(text, "<del>$1</del>")
+	return text
+}
+
+// removeNulBytes replaces NUL bytes of input, like browsers do, so that they can only come from placeholders
+func removeNulBytes(markdown string) string {
+	return strings.ReplaceAll(markdown, "\x00", "\uFFFD")
+}
+
+func splitLines(markdown string) []string {
+	return strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+}
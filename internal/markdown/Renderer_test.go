@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package markdown
+
+import (
+	"regexp"
+	"testing"
+)
+
+const newTabAttributes = ` target="_blank" rel="noopener noreferrer"`
+
+// unsafeOutputRegex matches markup which must never be left in rendered html, whatever the input
+var unsafeOutputRegex = regexp.MustCompile(`(?i)<\s*(?:script|style|iframe|svg|body|object|embed)|<[^>]*\son[a-z]+\s*=|(?:href|src)\s*=\s*"\s*(?:javascript|vbscript|data):`)
+
+func TestRenderHTMLSanitizesXss(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{name: "javascript link", markdown: `[x](javascript:alert(1))`, want: "<p><a" + newTabAttributes + ">x</a></p>\n"},
+		{name: "mixed case scheme", markdown: `[x](JaVaScRiPt:alert(1))`, want: "<p><a" + newTabAttributes + ">x</a></p>\n"},
+		{name: "javascript image", markdown: `![x](javascript:alert(1))`, want: "<p><img alt=\"x\"></p>\n"},
+		{name: "decimal entity scheme", markdown: `<a href="&#106;avascript:alert(1)">x</a>`, want: "<p><a" + newTabAttributes + ">x</a></p>\n"},
+		{name: "hex entity scheme", markdown: `<a href="&#x6A;avascript&#x3A;alert(1)">x</a>`, want: "<p><a" + newTabAttributes + ">x</a></p>\n"},
+		{name: "entity tab in scheme", markdown: `<a href="jav&#x09;ascript:alert(1)">x</a>`, want: "<p><a" + newTabAttributes + ">x</a></p>\n"},
+		{name: "leading space in scheme", markdown: `<a href=" javascript:alert(1)">x</a>`, want: "<p><a" + newTabAttributes + ">x</a></p>\n"},
+		{name: "data url", markdown: `<a href="data:text/html;base64,PHNjcmlwdD4=">x</a>`, want: "<p><a" + newTabAttributes + ">x</a></p>\n"},
+		{name: "vbscript url", markdown: `<a href="vbscript:msgbox(1)">x</a>`, want: "<p><a" + newTabAttributes + ">x</a></p>\n"},
+		{name: "unquoted event handler", markdown: `<img src=x onerror=alert(1)>`, want: "<p><img src=\"x\"></p>\n"},
+		{name: "quoted event handler", markdown: `<img src="x" onerror="alert(1)">`, want: "<p><img src=\"x\"></p>\n"},
+		{name: "upper case tag and handler", markdown: `<IMG SRC=x OnError=alert(1)>`, want: "<p><img src=\"x\"></p>\n"},
+		{name: "handler and style on link", markdown: `<a href="https://x" onclick="alert(1)" style="color:red">x</a>`, want: "<p><a href=\"https://x\"" + newTabAttributes + ">x</a></p>\n"},
+		{name: "handler on link without href", markdown: `<a title="x" onmouseover="alert(1)">x</a>`, want: "<p><a title=\"x\"" + newTabAttributes + ">x</a></p>\n"},
+		{name: "svg", markdown: `<svg onload=alert(1)>`, want: "<p></p>\n"},
+		{name: "body", markdown: `<body onload=alert(1)>`, want: "<p></p>\n"},
+		{name: "script", markdown: `<script>alert(1)</script>after`, want: "<p>after</p>\n"},
+		{name: "upper case script with src", markdown: `<SCRIPT SRC=//evil/x.js></SCRIPT>after`, want: "<p>after</p>\n"},
+		{name: "style", markdown: `<style>body{display:none}</style>after`, want: "<p>after</p>\n"},
+		{name: "iframe", markdown: `<iframe src="javascript:alert(1)"></iframe>after`, want: "<p>after</p>\n"},
+		{name: "script in comment", markdown: `<!--<script>alert(1)</script>-->after`, want: "<p>after</p>\n"},
+		{name: "script nested in allowed tags", markdown: `<div><details><summary>x</summary><script>alert(1)</script></details>`, want: "<details><summary>x</summary></details>\n"},
+		{name: "javascript image nested in link", markdown: `<a href="https://x"><img src="javascript:alert(1)"></a>`, want: "<p><a href=\"https://x\"" + newTabAttributes + "><img></a></p>\n"},
+		{name: "script split by script", markdown: `<scr<script>ipt>alert(1)</script>`, want: "<p>&lt;scr</p>\n"},
+		{name: "doubled brackets", markdown: `<<script>script>alert(1)<</script>/script>`, want: "<p>&lt;/script&gt;</p>\n"},
+		{name: "unterminated tag", markdown: `<a href="javascript:alert(1)"`, want: "<p>&lt;a href=&#34;javascript:alert(1)&#34;</p>\n"},
+		{name: "unterminated handler", markdown: `<img src=x onerror=alert(1)//`, want: "<p>&lt;img src=x onerror=alert(1)//</p>\n"},
+		{name: "script in code span", markdown: "`<script>alert(1)</script>`", want: "<p><code>&lt;script&gt;alert(1)&lt;/script&gt;</code></p>\n"},
+		{name: "nul bytes around number", markdown: "hello \x0099\x00 world", want: "<p>hello \uFFFD99\uFFFD world</p>\n"},
+		{name: "nul bytes in code span", markdown: "`\x000\x00`", want: "<p><code>\uFFFD0\uFFFD</code></p>\n"},
+		{name: "nul bytes around script", markdown: "\x000\x00<script>alert(1)</script>", want: "<p>\uFFFD0\uFFFD</p>\n"},
+		{name: "script in code block", markdown: "```\n<script>alert(1)</script>\n```", want: "<pre><code>&lt;script&gt;alert(1)&lt;/script&gt;</code></pre>\n"},
+	}
+	renderer := NewRenderer("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderer.RenderHTML(tt.markdown)
+			if got != tt.want {
+				t.Errorf("RenderHTML(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+			if unsafeOutputRegex.MatchString(got) {
+				t.Errorf("RenderHTML(%q) = %q, has unsafe markup", tt.markdown, got)
+			}
+			// sanitizing again must not change anything, output is safe to embed as is
+			if sanitized := Sanitize(got); sanitized != got {
+				t.Errorf("Sanitize(%q) = %q, want it unchanged", got, sanitized)
+			}
+		})
+	}
+}
+
+func TestRenderHTMLLinkWithParentheses(t *testing.T) {
+	tests := []struct {
+		markdown string
+		want     string
+	}{
+		{
+			markdown: "[Go](https://en.wikipedia.org/wiki/Go_(programming_language))",
+			want:     `<p><a href="https://en.wikipedia.org/wiki/Go_(programming_language)"` + newTabAttributes + ">Go</a></p>\n",
+		},
+		{
+			markdown: "see [docs](https://docs.devtron.ai/a_(b)) now)",
+			want:     `<p>see <a href="https://docs.devtron.ai/a_(b)"` + newTabAttributes + ">docs</a> now)</p>\n",
+		},
+		{
+			markdown: "![logo](https://devtron.ai/logo_(dark).png)",
+			want:     "<p><img src=\"https://devtron.ai/logo_(dark).png\" alt=\"logo\"></p>\n",
+		},
+		{
+			markdown: "(see [docs](https://docs.devtron.ai))",
+			want:     `<p>(see <a href="https://docs.devtron.ai"` + newTabAttributes + ">docs</a>)</p>\n",
+		},
+	}
+	renderer := NewRenderer("")
+	for _, tt := range tests {
+		if got := renderer.RenderHTML(tt.markdown); got != tt.want {
+			t.Errorf("RenderHTML(%q) = %q, want %q", tt.markdown, got, tt.want)
+		}
+	}
+}
+
+func TestRestorePlaceholders(t *testing.T) {
+	fragments := []string{"<code>x</code>", "<a>\x000\x00</a>", "\x002\x00"}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "a \x001\x00 b", want: "a <a><code>x</code></a> b"},
+		// fragment referring to itself or later fragments is not expanded
+		{text: "\x002\x00", want: ""},
+		{text: "\x0099\x00", want: ""},
+	}
+	for _, tt := range tests {
+		if got := restorePlaceholders(tt.text, fragments); got != tt.want {
+			t.Errorf("restorePlaceholders(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlTokenRegex     = regexp.MustCompile(`<!--[\s\S]*?-->|<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:\s+[^<>]*?)?)\s*(/?)>`)
+	htmlAttributeRegex = regexp.MustCompile(`([a-zA-Z_:][-a-zA-Z0-9_:.]*)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'=<>` + "`" + `]+)))?`)
+	htmlEntityRegex    = regexp.MustCompile(`^&(?:[a-zA-Z][a-zA-Z0-9]*|#[0-9]+|#[xX][0-9a-fA-F]+);`)
+)
+
+// allowedTags maps allowlisted tags to the attributes allowed on them
+var allowedTags = map[string]map[string]bool{
+	"a":          {"href": true, "title": true},
+	"b":          {},
+	"blockquote": {},
+	"br":         {},
+	"code":       {},
+	"del":        {},
+	"details":    {},
+	"em":         {},
+	"h1":         {},
+	"h2":         {},
+	"h3":         {},
+	"h4":         {},
+	"h5":         {},
+	"h6":         {},
+	"hr":         {},
+	"i":          {},
+	"img":        {"src": true, "alt": true, "title": true, "width": true, "height": true},
+	"kbd":        {},
+	"li":         {},
+	"ol":         {"start": true},
+	"p":          {},
+	"pre":        {},
+	"s":          {},
+	"strong":     {},
+	"sub":        {},
+	"summary":    {},
+	"sup":        {},
+	"table":      {},
+	"tbody":      {},
+	"td":         {"align": true},
+	"th":         {"align": true},
+	"thead":      {},
+	"tr":         {},
+	"ul":         {},
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+// droppedContentTags are removed along with everything inside them
+var droppedContentTags = map[string]bool{"script": true, "style": true, "iframe": true, "object": true, "embed": true, "noscript": true, "template": true}
+
+var urlAttributes = map[string]bool{"href": true, "src": true}
+
+// Sanitize keeps only allowlisted tags and attributes, drops comments and unsafe urls, escapes stray
+// markup in text, balances open tags and makes every link open in a new tab
+func Sanitize(input string) string {
+	var builder strings.Builder
+	var openTags []string
+	droppingUntil := ""
+	last := 0
+	for _, match := range htmlTokenRegex.FindAllStringSubmatchIndex(input, -1) {
+		if len(droppingUntil) == 0 {
+			builder.WriteString(escapeText(input[last:match[0]]))
+		}
+		last = match[1]
+		token := input[match[0]:match[1]]
+		if strings.HasPrefix(token, "<!--") {
+			continue
+		}
+		isClosing := match[3] > match[2]
+		tag := strings.ToLower(input[match[4]:match[5]])
+		if len(droppingUntil) > 0 {
+			if isClosing && tag == droppingUntil {
+				droppingUntil = ""
+			}
+			continue
+		}
+		if droppedContentTags[tag] {
+			if !isClosing && match[9] == match[8] {
+				droppingUntil = tag
+			}
+			continue
+		}
+		allowedAttributes, ok := allowedTags[tag]
+		if !ok {
+			continue
+		}
+		if isClosing {
+			openTags = closeTag(&builder, openTags, tag)
+			continue
+		}
+		builder.WriteString("<" + tag)
+		builder.WriteString(sanitizeAttributes(tag, input[match[6]:match[7]], allowedAttributes))
+		builder.WriteString(">")
+		if !voidTags[tag] {
+			openTags = append(openTags, tag)
+		}
+	}
+	if len(droppingUntil) == 0 {
+		builder.WriteString(escapeText(input[last:]))
+	}
+	for i := len(openTags) - 1; i >= 0; i-- {
+		builder.WriteString("</" + openTags[i] + ">")
+	}
+	return builder.String()
+}
+
+// closeTag closes tag along with the tags opened after it, closing tags without an open tag are dropped
+func closeTag(builder *strings.Builder, openTags []string, tag string) []string {
+	for i := len(openTags) - 1; i >= 0; i-- {
+		if openTags[i] != tag {
+			continue
+		}
+		for j := len(openTags) - 1; j >= i; j-- {
+			builder.WriteString("</" + openTags[j] + ">")
+		}
+		return openTags[:i]
+	}
+	return openTags
+}
+
+func sanitizeAttributes(tag string, rawAttributes string, allowedAttributes map[string]bool) string {
+	var builder strings.Builder
+	for _, match := range htmlAttributeRegex.FindAllStringSubmatch(rawAttributes, -1) {
+		name := strings.ToLower(match[1])
+		if !allowedAttributes[name] {
+			continue
+		}
+		value := html.UnescapeString(match[2] + match[3] + match[4])
+		if urlAttributes[name] {
+			if !isSafeUrl(value) {
+				continue
+			}
+		}
+		builder.WriteString(" " + name + "=\"" + html.EscapeString(value) + "\"")
+	}
+	if tag == "a" {
+		builder.WriteString(` target="_blank" rel="noopener noreferrer"`)
+	}
+	return builder.String()
+}
+
+func isSafeUrl(url string) bool {
+	normalized := strings.ToLower(strings.Map(func(r rune) rune {
+		// browsers ignore whitespace and control characters inside scheme, e.g. java\tscript:
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, url))
+	if len(normalized) == 0 {
+		return false
+	}
+	if strings.HasPrefix(normalized, "/") || strings.HasPrefix(normalized, "#") {
+		return true
+	}
+	for _, scheme := range []string{"http://", "https://", "mailto:"} {
+		if strings.HasPrefix(normalized, scheme) {
+			return true
+		}
+	}
+	// relative urls without scheme are allowed, anything having a scheme other than above is not
+	colon := strings.Index(normalized, ":")
+	return colon < 0 || (strings.ContainsAny(normalized[:colon], "/?#"))
+}
+
+// escapeText escapes markup characters in text, existing entities are kept as is
+func escapeText(text string) string {
+	var builder strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '<':
+			builder.WriteString("&lt;")
+		case '>':
+			builder.WriteString("&gt;")
+		case '"':
+			builder.WriteString("&#34;")
+		case '&':
+			if htmlEntityRegex.MatchString(text[i:]) {
+				builder.WriteByte('&')
+			} else {
+				builder.WriteString("&amp;")
+			}
+		default:
+			builder.WriteByte(text[i])
+		}
+	}
+	return builder.String()
+}
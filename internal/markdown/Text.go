@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlCommentRegex  = regexp.MustCompile(`<!--[\s\S]*?-->`)
+	scriptStyleRegex  = regexp.MustCompile(`(?is)<script\b.*?</script\s*>|<style\b.*?</style\s*>`)
+	blankLinesRegex   = regexp.MustCompile(`\n{3,}`)
+	quoteMarkerRegex  = regexp.MustCompile(`^>\s?`)
+	textListLineRegex = regexp.MustCompile(`^(\s*)(?:[-*+])\s+`)
+)
+
+// RenderText converts markdown to plain text for notifications and cli output, links are written as text (url).
+// Content of code blocks and code spans is written as is, markup looking like html inside them is kept.
+func (impl *Renderer) RenderText(markdown string) string {
+	var lines []string
+	var textLines []string
+	flushText := func() {
+		if len(textLines) > 0 {
+			lines = append(lines, impl.renderTextLines(textLines)...)
+			textLines = nil
+		}
+	}
+	inCodeBlock := false
+	for _, line := range splitLines(removeNulBytes(markdown)) {
+		if fenceRegex.MatchString(strings.TrimSpace(line)) {
+			flushText()
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			lines = append(lines, line)
+			continue
+		}
+		textLines = append(textLines, line)
+	}
+	flushText()
+	text := strings.Join(lines, "\n")
+	text = blankLinesRegex.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// renderTextLines renders lines between code blocks, code spans are kept in placeholders while
+// comments, scripts and inline html are removed
+func (impl *Renderer) renderTextLines(textLines []string) []string {
+	var codeSpans []string
+	for i, line := range textLines {
+		textLines[i] = codeSpanRegex.ReplaceAllStringFunc(line, func(match string) string {
+			codeSpans = append(codeSpans, codeSpanRegex.FindStringSubmatch(match)[1])
+			return fmt.Sprintf("\x00%d\x00", len(codeSpans)-1)
+		})
+	}
+	markdown := strings.Join(textLines, "\n")
+	markdown = htmlCommentRegex.ReplaceAllString(markdown, "")
+	markdown = scriptStyleRegex.ReplaceAllString(markdown, "")
+	var lines []string
+	for _, line := range splitLines(markdown) {
+		trimmed := strings.TrimSpace(line)
+		if ruleRegex.MatchString(trimmed) && len(trimmed) > 0 {
+			lines = append(lines, "")
+			continue
+		}
+		if match := headingLineRegex.FindStringSubmatch(trimmed); match != nil {
+			line = match[2]
+		}
+		line = quoteMarkerRegex.ReplaceAllString(line, "")
+		line = textListLineRegex.ReplaceAllString(line, "$1- ")
+		line = strings.TrimRight(impl.renderInlineText(line), " \t")
+		lines = append(lines, restorePlaceholders(line, codeSpans))
+	}
+	return lines
+}
+
+func (impl *Renderer) renderInlineText(text string) string {
+	text = imageRegex.ReplaceAllString(text, "$1")
+	text = linkRegex.ReplaceAllStringFunc(text, func(match string) string {
+		groups := linkRegex.FindStringSubmatch(match)
+		if groups[1] == groups[2] {
+			return groups[1]
+		}
+		return groups[1] + " (" + groups[2] + ")"
+	})
+	text = inlineHtmlRegex.ReplaceAllString(text, "")
+	text = strongRegex.ReplaceAllString(text, "$1$2")
+	text = emphasisRegex.ReplaceAllString(text, "$1$3$2$4")
+	text = strikeRegex.ReplaceAllString(text, "$1")
+	return html.UnescapeString(text)
+}
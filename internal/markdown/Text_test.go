@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package markdown
+
+import "testing"
+
+func TestRenderText(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{name: "html in code spans", markdown: "use `<div>` and `a <b> c` here", want: "use <div> and a <b> c here"},
+		{name: "comment markers in code spans", markdown: "`<!--` is not a comment `-->`", want: "<!-- is not a comment -->"},
+		{name: "entity in code span", markdown: "escape `&amp;` as &amp;", want: "escape &amp; as &"},
+		{name: "html outside code", markdown: "text <b>bold</b> <!-- gone --><script>alert(1)</script>", want: "text bold"},
+		{name: "html in code block", markdown: "```\n<!-- kept -->\n<script>x</script>\n```\n<!-- gone -->after", want: "<!-- kept -->\n<script>x</script>\nafter"},
+		{name: "link with parentheses", markdown: "[Go](https://en.wikipedia.org/wiki/Go_(programming_language)) after", want: "Go (https://en.wikipedia.org/wiki/Go_(programming_language)) after"},
+		{name: "nul bytes around number", markdown: "hello \x0099\x00 world", want: "hello \uFFFD99\uFFFD world"},
+		{name: "nul bytes in code span", markdown: "`\x000\x00` and `x`", want: "\uFFFD0\uFFFD and x"},
+		{name: "code span in link text", markdown: "- [`helm`](https://helm.sh) support", want: "- helm (https://helm.sh) support"},
+	}
+	renderer := NewRenderer("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderer.RenderText(tt.markdown); got != tt.want {
+				t.Errorf("RenderText(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	metricTypeCounter = "counter"
+	metricTypeGauge   = "gauge"
+)
+
+// registry holds every metric created in this process, metrics are exposed on /metrics in prometheus text format
+var registry = &metricRegistry{}
+
+type metricRegistry struct {
+	mutex   sync.RWMutex
+	metrics []*metricVec
+}
+
+func (impl *metricRegistry) register(metric *metricVec) {
+	impl.mutex.Lock()
+	defer impl.mutex.Unlock()
+	for _, existing := range impl.metrics {
+		if existing.name == metric.name {
+			panic(fmt.Sprintf("metric %s registered twice", metric.name))
+		}
+	}
+	impl.metrics = append(impl.metrics, metric)
+}
+
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+type metricVec struct {
+	name       string
+	help       string
+	metricType string
+	labelNames []string
+	mutex      sync.Mutex
+	samples    map[string]*sample
+}
+
+func newMetricVec(name string, help string, metricType string, labelNames []string) *metricVec {
+	metric := &metricVec{
+		name:       name,
+		help:       help,
+		metricType: metricType,
+		labelNames: labelNames,
+		samples:    make(map[string]*sample),
+	}
+	registry.register(metric)
+	return metric
+}
+
+func (impl *metricVec) update(labelValues []string, update func(value float64) float64) {
+	if len(labelValues) != len(impl.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", impl.name, len(impl.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	impl.mutex.Lock()
+	defer impl.mutex.Unlock()
+	metricSample, ok := impl.samples[key]
+	if !ok {
+		metricSample = &sample{labelValues: append([]string{}, labelValues...)}
+		impl.samples[key] = metricSample
+	}
+	metricSample.value = update(metricSample.value)
+}
+
+// CounterVec is a monotonically increasing value partitioned by labels
+type CounterVec struct {
+	metric *metricVec
+}
+
+func NewCounterVec(name string, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{metric: newMetricVec(name, help, metricTypeCounter, labelNames)}
+}
+
+func (impl *CounterVec) Inc(labelValues ...string) {
+	impl.Add(1, labelValues...)
+}
+
+func (impl *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	impl.metric.update(labelValues, func(value float64) float64 {
+		return value + delta
+	})
+}
+
+// GaugeVec is a value which can go up and down partitioned by labels
+type GaugeVec struct {
+	metric *metricVec
+}
+
+func NewGaugeVec(name string, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{metric: newMetricVec(name, help, metricTypeGauge, labelNames)}
+}
+
+func (impl *GaugeVec) Set(value float64, labelValues ...string) {
+	impl.metric.update(labelValues, func(float64) float64 {
+		return value
+	})
+}
+
+// Handler writes all the registered metrics in prometheus text exposition format
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		registry.mutex.RLock()
+		defer registry.mutex.RUnlock()
+		for _, metric := range registry.metrics {
+			writeMetric(w, metric)
+		}
+	})
+}
+
+func writeMetric(w http.ResponseWriter, metric *metricVec) {
+	metric.mutex.Lock()
+	defer metric.mutex.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n", metric.name, metric.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", metric.name, metric.metricType)
+	keys := make([]string, 0, len(metric.samples))
+	for key := range metric.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		metricSample := metric.samples[key]
+		var labels []string
+		for i, labelName := range metric.labelNames {
+			labels = append(labels, fmt.Sprintf("%s=%q", labelName, metricSample.labelValues[i]))
+		}
+		if len(labels) > 0 {
+			fmt.Fprintf(w, "%s{%s} %g\n", metric.name, strings.Join(labels, ","), metricSample.value)
+		} else {
+			fmt.Fprintf(w, "%s %g\n", metric.name, metricSample.value)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version, tags like v0.7.2 and 0.7.2-rc.1 are both accepted
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease []string
+	Original   string
+}
+
+// Parse parses tag name into Version, missing minor and patch are treated as 0 and build metadata is ignored
+func Parse(tagName string) (*Version, error) {
+	original := tagName
+	tagName = strings.TrimSpace(tagName)
+	tagName = strings.TrimPrefix(strings.TrimPrefix(tagName, "v"), "V")
+	if index := strings.Index(tagName, "+"); index >= 0 {
+		tagName = tagName[:index]
+	}
+	var preRelease []string
+	if index := strings.Index(tagName, "-"); index >= 0 {
+		preReleaseString := tagName[index+1:]
+		if len(preReleaseString) == 0 {
+			return nil, fmt.Errorf("invalid semver %q, empty pre-release", original)
+		}
+		preRelease = strings.Split(preReleaseString, ".")
+		tagName = tagName[:index]
+	}
+	parts := strings.Split(tagName, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid semver %q", original)
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return nil, fmt.Errorf("invalid semver %q", original)
+		}
+		numbers[i] = number
+	}
+	return &Version{
+		Major:      numbers[0],
+		Minor:      numbers[1],
+		Patch:      numbers[2],
+		PreRelease: preRelease,
+		Original:   original,
+	}, nil
+}
+
+// IsPreRelease returns true when version has a pre-release suffix like -rc.1 or -beta
+func (v *Version) IsPreRelease() bool {
+	return len(v.PreRelease) > 0
+}
+
+// Compare returns -1, 0 or 1 if v is lower, equal or greater than other as per semver precedence rules
+func (v *Version) Compare(other *Version) int {
+	if result := compareInt(v.Major, other.Major); result != 0 {
+		return result
+	}
+	if result := compareInt(v.Minor, other.Minor); result != 0 {
+		return result
+	}
+	if result := compareInt(v.Patch, other.Patch); result != 0 {
+		return result
+	}
+	// a version without pre-release has higher precedence
+	if len(v.PreRelease) == 0 || len(other.PreRelease) == 0 {
+		return compareInt(len(other.PreRelease), len(v.PreRelease))
+	}
+	for i := 0; i < len(v.PreRelease) && i < len(other.PreRelease); i++ {
+		if result := comparePreReleaseIdentifier(v.PreRelease[i], other.PreRelease[i]); result != 0 {
+			return result
+		}
+	}
+	return compareInt(len(v.PreRelease), len(other.PreRelease))
+}
+
+// Compare parses both the tag names and compares them, see Version.Compare
+func Compare(tagName1 string, tagName2 string) (int, error) {
+	version1, err := Parse(tagName1)
+	if err != nil {
+		return 0, err
+	}
+	version2, err := Parse(tagName2)
+	if err != nil {
+		return 0, err
+	}
+	return version1.Compare(version2), nil
+}
+
+func comparePreReleaseIdentifier(identifier1 string, identifier2 string) int {
+	number1, err1 := strconv.Atoi(identifier1)
+	number2, err2 := strconv.Atoi(identifier2)
+	switch {
+	case err1 == nil && err2 == nil:
+		return compareInt(number1, number2)
+	case err1 == nil:
+		// numeric identifiers have lower precedence than alphanumeric ones
+		return -1
+	case err2 == nil:
+		return 1
+	}
+	return strings.Compare(identifier1, identifier2)
+}
+
+func compareInt(a int, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
@@ -30,7 +30,7 @@ func main() {
 		log.Panic(err)
 	}
 	//     gracefulStop start
-	var gracefulStop = make(chan os.Signal)
+	var gracefulStop = make(chan os.Signal, 1)
 	signal.Notify(gracefulStop, syscall.SIGTERM)
 	signal.Notify(gracefulStop, syscall.SIGINT)
 	go func() {
@@ -41,4 +41,6 @@ func main() {
 	}()
 	//      gracefulStop end
 	app.Start()
+	// server is closed by Stop, wait for it to finish draining and exit
+	select {}
 }
@@ -0,0 +1,256 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/common"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	GitLabTokenHeader        = "X-Gitlab-Token"
+	GitLabEventTypeHeader    = "X-Gitlab-Event"
+	GitLabEventUuidHeader    = "X-Gitlab-Event-UUID"
+	GitLabReleaseEventType   = "Release Hook"
+	gitLabReleasesPerPage    = 100
+	gitLabWebhookTimeLayout  = "2006-01-02 15:04:05 MST"
+	gitLabActionCreate       = "create"
+	gitLabActionUpdate       = "update"
+	gitLabActionDelete       = "delete"
+	gitLabNextPageHeader     = "X-Next-Page"
+	gitLabPrivateTokenHeader = "PRIVATE-TOKEN"
+)
+
+// gitLabTime accepts ISO 8601 timestamps of rest api and "2020-11-05 12:29:37 UTC" sent in webhooks
+type gitLabTime struct {
+	time.Time
+}
+
+func (t *gitLabTime) UnmarshalJSON(data []byte) error {
+	var value string
+	err := json.Unmarshal(data, &value)
+	if err != nil || len(value) == 0 {
+		// null timestamps are left empty
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		parsed, err = time.Parse(gitLabWebhookTimeLayout, value)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", value)
+	}
+	t.Time = parsed
+	return nil
+}
+
+type gitLabRelease struct {
+	TagName         string      `json:"tag_name"`
+	Name            string      `json:"name"`
+	Description     string      `json:"description"`
+	CreatedAt       *gitLabTime `json:"created_at"`
+	ReleasedAt      *gitLabTime `json:"released_at"`
+	UpcomingRelease bool        `json:"upcoming_release"`
+	Links           struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+}
+
+type gitLabReleaseEvent struct {
+	ObjectKind  string      `json:"object_kind"`
+	Action      string      `json:"action"`
+	Tag         string      `json:"tag"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	CreatedAt   *gitLabTime `json:"created_at"`
+	ReleasedAt  *gitLabTime `json:"released_at"`
+	Url         string      `json:"url"`
+	Project     struct {
+		Id                int64  `json:"id"`
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+}
+
+type GitLabReleaseSourceImpl struct {
+	logger     *zap.SugaredLogger
+	config     *util.ReleaseSourceConfig
+	httpClient *http.Client
+}
+
+func NewGitLabReleaseSourceImpl(logger *zap.SugaredLogger, config *util.ReleaseSourceConfig) *GitLabReleaseSourceImpl {
+	return &GitLabReleaseSourceImpl{
+		logger:     logger,
+		config:     config,
+		httpClient: &http.Client{Timeout: releaseSourceHttpTimeout},
+	}
+}
+
+func (impl *GitLabReleaseSourceImpl) Provider() string {
+	return util.GITLAB_PROVIDER
+}
+
+func (impl *GitLabReleaseSourceImpl) Repos() []string {
+	return []string{impl.config.GitLabProject}
+}
+
+func (impl *GitLabReleaseSourceImpl) ListReleases(repo string) ([]*common.Release, error) {
+	header := http.Header{}
+	if len(impl.config.GitLabToken) > 0 {
+		header.Set(gitLabPrivateTokenHeader, impl.config.GitLabToken)
+	}
+	baseUrl := fmt.Sprintf("%s/api/v4/projects/%s/releases", strings.TrimSuffix(impl.config.GitLabHost, "/"),
+		url.PathEscape(repo))
+	var releases []*common.Release
+	page := "1"
+	for len(page) > 0 {
+		var pageReleases []*gitLabRelease
+		responseHeader, err := getJson(impl.httpClient, fmt.Sprintf("%s?per_page=%d&page=%s", baseUrl, gitLabReleasesPerPage, page), header, &pageReleases)
+		if err != nil {
+			return releases, err
+		}
+		for _, item := range pageReleases {
+			if item == nil {
+				continue
+			}
+			releases = append(releases, impl.adaptGitLabRelease(repo, item))
+		}
+		if isReleasesMaxCountReached(impl.logger, releases, impl.ReleasesMaxCount()) {
+			releases = releases[:impl.ReleasesMaxCount()]
+			break
+		}
+		page = responseHeader.Get(gitLabNextPageHeader)
+	}
+	return releases, nil
+}
+
+func (impl *GitLabReleaseSourceImpl) ReleasesMaxCount() int {
+	return impl.config.ReleasesMaxCount
+}
+
+// ValidateWebhook compares X-Gitlab-Token with configured token, gitlab does not sign payloads
+func (impl *GitLabReleaseSourceImpl) ValidateWebhook(r *http.Request, requestBodyBytes []byte) bool {
+	webhookToken := impl.config.GitLabWebhookToken
+	if len(webhookToken) == 0 {
+		impl.logger.Errorw("GITLAB_WEBHOOK_TOKEN is not configured, rejecting webhook")
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(GitLabTokenHeader)), []byte(webhookToken)) == 1
+}
+
+// GetWebhookEventType maps "Release Hook" to release, other hooks become e.g. push_hook and are acknowledged
+func (impl *GitLabReleaseSourceImpl) GetWebhookEventType(r *http.Request) string {
+	eventType := r.Header.Get(GitLabEventTypeHeader)
+	if eventType == GitLabReleaseEventType {
+		return EventTypeRelease
+	}
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(eventType)), " ", "_")
+}
+
+// IssueBaseUrl returns issues url of project, empty when project is configured by numeric id as web urls
+// need the project path
+func (impl *GitLabReleaseSourceImpl) IssueBaseUrl(repo string) string {
+	if _, err := strconv.ParseInt(repo, 10, 64); err == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/-/issues", strings.TrimSuffix(impl.config.GitLabHost, "/"), strings.Trim(repo, "/"))
+}
+
+func (impl *GitLabReleaseSourceImpl) GetWebhookDeliveryId(r *http.Request) string {
+	return r.Header.Get(GitLabEventUuidHeader)
+}
+
+// WebhookCredentialHeaders returns X-Gitlab-Token, gitlab sends the webhook token as is
+func (impl *GitLabReleaseSourceImpl) WebhookCredentialHeaders() []string {
+	return []string{GitLabTokenHeader}
+}
+
+func (impl *GitLabReleaseSourceImpl) DecodeReleaseEvent(requestBodyBytes []byte) (*ReleaseEvent, error) {
+	releaseEvent := &gitLabReleaseEvent{}
+	invalidPayloadError := decodePayload(requestBodyBytes, releaseEvent)
+	if len(invalidPayloadError.FieldErrors) > 0 {
+		return nil, invalidPayloadError
+	}
+	var action string
+	switch releaseEvent.Action {
+	case gitLabActionCreate:
+		action = ActionCreated
+	case gitLabActionUpdate:
+		action = ActionEdited
+	case gitLabActionDelete:
+		action = ActionDeleted
+	case "":
+		invalidPayloadError.addFieldError("action", "required")
+	default:
+		// unknown actions are ignored by UpdateReleases
+		action = releaseEvent.Action
+	}
+	validateTagName(invalidPayloadError, "tag", releaseEvent.Tag)
+	if len(invalidPayloadError.FieldErrors) > 0 {
+		return nil, invalidPayloadError
+	}
+	release := &common.Release{
+		TagName:     releaseEvent.Tag,
+		ReleaseName: releaseEvent.Name,
+		Body:        releaseEvent.Description,
+		TagLink:     releaseEvent.Url,
+		CreatedAt:   releaseEvent.CreatedAt.get(),
+		PublishedAt: releaseEvent.ReleasedAt.get(),
+	}
+	// releases scheduled for future are not served till they are released
+	release.Draft = release.PublishedAt.After(time.Now())
+	return &ReleaseEvent{Action: action, Repo: impl.getEventRepo(releaseEvent), Release: release}, nil
+}
+
+// getEventRepo returns GITLAB_PROJECT when it is the numeric id of payload project, gitlab sends path otherwise
+func (impl *GitLabReleaseSourceImpl) getEventRepo(releaseEvent *gitLabReleaseEvent) string {
+	if releaseEvent.Project.Id > 0 && impl.config.GitLabProject == strconv.FormatInt(releaseEvent.Project.Id, 10) {
+		return impl.config.GitLabProject
+	}
+	return releaseEvent.Project.PathWithNamespace
+}
+
+func (impl *GitLabReleaseSourceImpl) adaptGitLabRelease(repo string, item *gitLabRelease) *common.Release {
+	tagLink := item.Links.Self
+	if len(tagLink) == 0 {
+		tagLink = fmt.Sprintf("%s/%s/-/releases/%s", strings.TrimSuffix(impl.config.GitLabHost, "/"), repo, item.TagName)
+	}
+	return &common.Release{
+		TagName:     item.TagName,
+		ReleaseName: item.Name,
+		Body:        item.Description,
+		TagLink:     tagLink,
+		CreatedAt:   item.CreatedAt.get(),
+		PublishedAt: item.ReleasedAt.get(),
+		Draft:       item.UpcomingRelease,
+	}
+}
+
+func (t *gitLabTime) get() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Time
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/google/go-github/github"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	GiteaSignatureHeader  = "X-Gitea-Signature"
+	GiteaEventTypeHeader  = "X-Gitea-Event"
+	GiteaDeliveryHeader   = "X-Gitea-Delivery"
+	giteaReleasesPerPage  = 50
+	giteaActionUpdated    = "updated"
+	giteaAuthHeaderPrefix = "token "
+)
+
+// GiteaReleaseSourceImpl reads releases from gitea, its release api and webhook payload follow github's shape
+type GiteaReleaseSourceImpl struct {
+	logger     *zap.SugaredLogger
+	config     *util.ReleaseSourceConfig
+	httpClient *http.Client
+}
+
+func NewGiteaReleaseSourceImpl(logger *zap.SugaredLogger, config *util.ReleaseSourceConfig) *GiteaReleaseSourceImpl {
+	return &GiteaReleaseSourceImpl{
+		logger:     logger,
+		config:     config,
+		httpClient: &http.Client{Timeout: releaseSourceHttpTimeout},
+	}
+}
+
+func (impl *GiteaReleaseSourceImpl) Provider() string {
+	return util.GITEA_PROVIDER
+}
+
+func (impl *GiteaReleaseSourceImpl) Repos() []string {
+	return []string{impl.config.GiteaOwner + "/" + impl.config.GiteaRepo}
+}
+
+// ListReleases reads pages till a page has less than page size releases or ReleasesMaxCount releases are
+// collected, gitea has no next page cursor
+func (impl *GiteaReleaseSourceImpl) ListReleases(repo string) ([]*common.Release, error) {
+	owner, name := util.SplitRepoFullName(repo)
+	header := http.Header{}
+	if len(impl.config.GiteaToken) > 0 {
+		header.Set("Authorization", giteaAuthHeaderPrefix+impl.config.GiteaToken)
+	}
+	baseUrl := fmt.Sprintf("%s/api/v1/repos/%s/%s/releases", strings.TrimSuffix(impl.config.GiteaHost, "/"),
+		url.PathEscape(owner), url.PathEscape(name))
+	var releases []*common.Release
+	for page := 1; ; page++ {
+		var pageReleases []*github.RepositoryRelease
+		_, err := getJson(impl.httpClient, fmt.Sprintf("%s?limit=%d&page=%d", baseUrl, giteaReleasesPerPage, page), header, &pageReleases)
+		if err != nil {
+			return releases, err
+		}
+		for _, item := range pageReleases {
+			if item == nil {
+				continue
+			}
+			releases = append(releases, adaptGiteaRelease(item))
+		}
+		if isReleasesMaxCountReached(impl.logger, releases, impl.ReleasesMaxCount()) {
+			releases = releases[:impl.ReleasesMaxCount()]
+			break
+		}
+		if len(pageReleases) < giteaReleasesPerPage {
+			break
+		}
+	}
+	return releases, nil
+}
+
+func (impl *GiteaReleaseSourceImpl) ReleasesMaxCount() int {
+	return impl.config.ReleasesMaxCount
+}
+
+// ValidateWebhook compares X-Gitea-Signature with hex HMAC-SHA256 of body, signature has no algorithm prefix
+func (impl *GiteaReleaseSourceImpl) ValidateWebhook(r *http.Request, requestBodyBytes []byte) bool {
+	webhookSecret := impl.config.GiteaWebhookSecret
+	if len(webhookSecret) == 0 {
+		impl.logger.Errorw("GITEA_WEBHOOK_SECRET is not configured, rejecting webhook")
+		return false
+	}
+	signature, err := hex.DecodeString(r.Header.Get(GiteaSignatureHeader))
+	if err != nil || len(signature) == 0 {
+		impl.logger.Errorw("invalid gitea signature header")
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(webhookSecret))
+	mac.Write(requestBodyBytes)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+func (impl *GiteaReleaseSourceImpl) GetWebhookEventType(r *http.Request) string {
+	return r.Header.Get(GiteaEventTypeHeader)
+}
+
+// IssueBaseUrl returns issues url of repo, gitea redirects issue links to pull requests as both share
+// the same number space
+func (impl *GiteaReleaseSourceImpl) IssueBaseUrl(repo string) string {
+	return fmt.Sprintf("%s/%s/issues", strings.TrimSuffix(impl.config.GiteaHost, "/"), repo)
+}
+
+func (impl *GiteaReleaseSourceImpl) GetWebhookDeliveryId(r *http.Request) string {
+	return r.Header.Get(GiteaDeliveryHeader)
+}
+
+// WebhookCredentialHeaders is empty, gitea only sends the payload signature
+func (impl *GiteaReleaseSourceImpl) WebhookCredentialHeaders() []string {
+	return nil
+}
+
+func (impl *GiteaReleaseSourceImpl) DecodeReleaseEvent(requestBodyBytes []byte) (*ReleaseEvent, error) {
+	releaseEvent, err := DecodeGitHubReleaseEvent(requestBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	action := releaseEvent.GetAction()
+	if action == giteaActionUpdated {
+		action = ActionEdited
+	}
+	return &ReleaseEvent{
+		Action:  action,
+		Repo:    releaseEvent.GetRepo().GetFullName(),
+		Release: adaptGiteaRelease(releaseEvent.Release),
+	}, nil
+}
+
+// adaptGiteaRelease links to release page of gitea instead of github tag link
+func adaptGiteaRelease(item *github.RepositoryRelease) *common.Release {
+	release := adaptGithubRelease(item)
+	release.TagLink = item.GetHTMLURL()
+	return release
+}
@@ -0,0 +1,323 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/pkg/blobStorage"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReleaseSnapshotSchemaVersion is bumped on incompatible changes of releaseSnapshot, it is part of blob key
+// so that replicas of different versions never read each other's snapshot
+const ReleaseSnapshotSchemaVersion = 1
+
+// LATEST_FILENAME is latest tag pointer, it is only written for readers outside central-api as edits and deletes
+// of older releases do not move it. Staleness is checked with RELEASE_SNAPSHOT_HASH_FILENAME.
+const LATEST_FILENAME = "latest.txt"
+
+// LATEST_CHANNEL_FILENAME_FORMAT is latest tag pointer of a channel feed, it is only written for readers outside
+// central-api
+const LATEST_CHANNEL_FILENAME_FORMAT = "latest-%s.txt"
+
+var RELEASE_SNAPSHOT_FILENAME = fmt.Sprintf("releases.v%d.json.gz", ReleaseSnapshotSchemaVersion)
+
+// RELEASE_SNAPSHOT_HASH_FILENAME holds content hash of the latest snapshot, it is written after everything else
+var RELEASE_SNAPSHOT_HASH_FILENAME = fmt.Sprintf("releases.v%d.sha256", ReleaseSnapshotSchemaVersion)
+
+// ErrReleasesNotLoaded is returned by Save and Delete of a replica whose releases are neither loaded from snapshot
+// nor replaced with a full fetch, publishing its partial list would overwrite releases of every replica
+var ErrReleasesNotLoaded = errors.New("releases are not loaded from snapshot or source yet")
+
+// releaseSnapshot is the full release list stored gzip compressed in blob storage. ContentHash is compared
+// with snapshot hash pointer, snapshot is stale when they differ.
+type releaseSnapshot struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	LatestTag     string            `json:"latestTag"`
+	ContentHash   string            `json:"contentHash"`
+	CreatedOn     time.Time         `json:"createdOn"`
+	Releases      []*common.Release `json:"releases"`
+}
+
+// ReleaseBlobRepositoryImpl serves releases from release store, every change is published as snapshot
+// and snapshot hash pointer through blob adapter so that other replicas can pick it up
+type ReleaseBlobRepositoryImpl struct {
+	logger       *zap.SugaredLogger
+	blobAdapter  blobStorage.BlobAdapter
+	releaseStore ReleaseStore
+	repo         string
+	// blob keys of the repo start with keyPrefix, see getReleaseBlobKeyPrefix
+	latestKey                string
+	snapshotKey              string
+	snapshotHashKey          string
+	keyPrefix                string
+	releaseChannelClassifier ReleaseChannelClassifier
+	// contentHash is hash of releases in release store, empty until they are published or loaded from snapshot
+	contentHash     string
+	contentHashLock sync.RWMutex
+	// publishLock is held while release store is changed and published, so that blob keys are always written
+	// in the order of store generations
+	publishLock sync.Mutex
+}
+
+func NewReleaseBlobRepositoryImpl(logger *zap.SugaredLogger, blobAdapter blobStorage.BlobAdapter, releaseStore ReleaseStore,
+	repo string, isPrimary bool, releaseChannelClassifier ReleaseChannelClassifier) *ReleaseBlobRepositoryImpl {
+	keyPrefix := getReleaseBlobKeyPrefix(repo, isPrimary)
+	return &ReleaseBlobRepositoryImpl{
+		logger:                   logger,
+		blobAdapter:              blobAdapter,
+		releaseStore:             releaseStore,
+		repo:                     repo,
+		latestKey:                keyPrefix + LATEST_FILENAME,
+		snapshotKey:              keyPrefix + RELEASE_SNAPSHOT_FILENAME,
+		snapshotHashKey:          keyPrefix + RELEASE_SNAPSHOT_HASH_FILENAME,
+		keyPrefix:                keyPrefix,
+		releaseChannelClassifier: releaseChannelClassifier,
+	}
+}
+
+// getReleaseBlobKeyPrefix keeps keys of primary repo unprefixed so that snapshots published before multi repo
+// support are still read, other repos get owner_repo_ prefix as blob keys are flat names
+func getReleaseBlobKeyPrefix(repo string, isPrimary bool) string {
+	if isPrimary {
+		return ""
+	}
+	return strings.ReplaceAll(repo, "/", "_") + "_"
+}
+
+func (impl *ReleaseBlobRepositoryImpl) List(offset int, size int) ([]*common.Release, error) {
+	return paginateReleases(filterPublishedReleases(impl.releaseStore.Get()), offset, size), nil
+}
+
+// Save and Delete change the latest snapshot, releases published by other replicas are loaded first and
+// ErrReleasesNotLoaded is returned when there is no snapshot to change
+func (impl *ReleaseBlobRepositoryImpl) Save(release *common.Release) error {
+	// store replaces release having same tag or adds it as latest
+	return impl.changeReleases(func() []*common.Release {
+		return impl.releaseStore.Upsert(release)
+	})
+}
+
+func (impl *ReleaseBlobRepositoryImpl) Delete(tagName string) error {
+	// latest pointer has to move back if latest release was removed
+	return impl.changeReleases(func() []*common.Release {
+		return impl.releaseStore.Remove(tagName)
+	})
+}
+
+func (impl *ReleaseBlobRepositoryImpl) ReplaceAll(releases []*common.Release) error {
+	impl.publishLock.Lock()
+	defer impl.publishLock.Unlock()
+	return impl.publishReleases(impl.releaseStore.Replace(releases))
+}
+
+func (impl *ReleaseBlobRepositoryImpl) changeReleases(change func() []*common.Release) error {
+	impl.publishLock.Lock()
+	defer impl.publishLock.Unlock()
+	stale, err := impl.loadLatestSnapshot()
+	if err != nil {
+		return err
+	}
+	if stale {
+		impl.logger.Warnw("not publishing change of releases, releases are not loaded", "repo", impl.repo)
+		return ErrReleasesNotLoaded
+	}
+	return impl.publishReleases(change())
+}
+
+// IsStale compares content hash of release store with snapshot hash pointer, so that edits and deletes of any
+// release are picked up. Releases published by another replica are loaded from snapshot, store is stale only when
+// snapshot is missing or does not match the pointer.
+func (impl *ReleaseBlobRepositoryImpl) IsStale() (bool, error) {
+	contentHash, found, err := impl.getSnapshotHash()
+	if err != nil {
+		return false, err
+	}
+	if found && len(impl.releaseStore.Get()) > 0 && impl.getContentHash() == contentHash {
+		return false, nil
+	}
+	impl.publishLock.Lock()
+	defer impl.publishLock.Unlock()
+	return impl.loadLatestSnapshot()
+}
+
+// loadLatestSnapshot loads snapshot into release store unless store already has it, returns true if there is no
+// usable snapshot. publishLock has to be held so that a snapshot is never loaded over an unpublished change.
+func (impl *ReleaseBlobRepositoryImpl) loadLatestSnapshot() (bool, error) {
+	contentHash, found, err := impl.getSnapshotHash()
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		// fresh bucket or snapshot never published after upgrade, releases have to be fetched from source
+		impl.logger.Infow("release snapshot hash not found", "key", impl.snapshotHashKey)
+		return true, nil
+	}
+	if len(impl.releaseStore.Get()) > 0 && impl.getContentHash() == contentHash {
+		return false, nil
+	}
+	return !impl.loadReleasesFromSnapshot(contentHash), nil
+}
+
+// Version is generation of release store, snapshots of other replicas are loaded into it by IsStale
+func (impl *ReleaseBlobRepositoryImpl) Version() (string, error) {
+	return strconv.FormatUint(impl.releaseStore.Generation(), 10), nil
+}
+
+// publishReleases is called with publishLock held, it uploads release snapshot and then moves latest tag and
+// snapshot hash pointers, readers which see the new hash are guaranteed to find a snapshot at least as new as the hash
+func (impl *ReleaseBlobRepositoryImpl) publishReleases(releaseList []*common.Release) error {
+	latestTag := getLatestPublishedTag(releaseList)
+	contentHash, err := getReleasesContentHash(releaseList)
+	if err != nil {
+		impl.logger.Errorw("error in hashing releases", "err", err)
+		return err
+	}
+	err = impl.uploadReleaseSnapshot(latestTag, contentHash, releaseList)
+	if err != nil {
+		impl.logger.Errorw("error in uploading release snapshot", "latestTag", latestTag, "err", err)
+		return err
+	}
+	for _, channel := range impl.releaseChannelClassifier.Channels() {
+		channelTag := getLatestPublishedTag(impl.releaseChannelClassifier.Filter(releaseList, channel))
+		err = impl.blobAdapter.Put(impl.keyPrefix+fmt.Sprintf(LATEST_CHANNEL_FILENAME_FORMAT, channel), []byte(channelTag))
+		if err != nil {
+			impl.logger.Errorw("error in updating latest tag of channel on blob", "channel", channel, "tagName", channelTag, "err", err)
+			return err
+		}
+	}
+	err = impl.blobAdapter.Put(impl.latestKey, []byte(latestTag))
+	if err != nil {
+		impl.logger.Errorw("error in updating latest tag on blob", "tagName", latestTag, "err", err)
+		return err
+	}
+	err = impl.blobAdapter.Put(impl.snapshotHashKey, []byte(contentHash))
+	if err != nil {
+		impl.logger.Errorw("error in updating snapshot hash on blob", "contentHash", contentHash, "err", err)
+		return err
+	}
+	impl.setContentHash(contentHash)
+	return nil
+}
+
+// getSnapshotHash returns content of snapshot hash pointer, found is false when the pointer does not exist
+func (impl *ReleaseBlobRepositoryImpl) getSnapshotHash() (contentHash string, found bool, err error) {
+	content, err := impl.blobAdapter.Get(impl.snapshotHashKey)
+	if errors.Is(err, blobStorage.ErrBlobNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		impl.logger.Errorw("error in getting snapshot hash from blob", "key", impl.snapshotHashKey, "err", err)
+		return "", false, err
+	}
+	return strings.TrimSpace(string(content)), true, nil
+}
+
+func (impl *ReleaseBlobRepositoryImpl) getContentHash() string {
+	impl.contentHashLock.RLock()
+	defer impl.contentHashLock.RUnlock()
+	return impl.contentHash
+}
+
+func (impl *ReleaseBlobRepositoryImpl) setContentHash(contentHash string) {
+	impl.contentHashLock.Lock()
+	defer impl.contentHashLock.Unlock()
+	impl.contentHash = contentHash
+}
+
+// getReleasesContentHash is sha256 of json encoded releases, it changes on any change of any release
+func getReleasesContentHash(releaseList []*common.Release) (string, error) {
+	content, err := json.Marshal(releaseList)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// loadReleasesFromSnapshot fills release store from blob snapshot if it has contentHash,
+// returns false when snapshot is missing, unreadable or stale
+func (impl *ReleaseBlobRepositoryImpl) loadReleasesFromSnapshot(contentHash string) bool {
+	snapshot, err := impl.downloadReleaseSnapshot()
+	if err != nil {
+		impl.logger.Warnw("release snapshot not available", "err", err)
+		return false
+	}
+	if snapshot.SchemaVersion != ReleaseSnapshotSchemaVersion || snapshot.ContentHash != contentHash || len(snapshot.Releases) == 0 {
+		impl.logger.Infow("release snapshot is stale", "snapshotHash", snapshot.ContentHash, "contentHash", contentHash, "schemaVersion", snapshot.SchemaVersion)
+		return false
+	}
+	for _, release := range snapshot.Releases {
+		// sections are not serialized, they are parsed again from body
+		release.Sections = ParseReleaseBody(release.Body)
+		// snapshots published before multi repo support have no repo
+		release.Repo = impl.repo
+	}
+	impl.logger.Infow("warmed up releases from snapshot", "latestTag", snapshot.LatestTag, "count", len(snapshot.Releases), "createdOn", snapshot.CreatedOn)
+	impl.releaseStore.Replace(snapshot.Releases)
+	impl.setContentHash(contentHash)
+	return true
+}
+
+func (impl *ReleaseBlobRepositoryImpl) uploadReleaseSnapshot(latestTag string, contentHash string, releaseList []*common.Release) error {
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	err := json.NewEncoder(gzipWriter).Encode(&releaseSnapshot{
+		SchemaVersion: ReleaseSnapshotSchemaVersion,
+		LatestTag:     latestTag,
+		ContentHash:   contentHash,
+		CreatedOn:     time.Now(),
+		Releases:      releaseList,
+	})
+	if err != nil {
+		return err
+	}
+	err = gzipWriter.Close()
+	if err != nil {
+		return err
+	}
+	return impl.blobAdapter.Put(impl.snapshotKey, buffer.Bytes())
+}
+
+func (impl *ReleaseBlobRepositoryImpl) downloadReleaseSnapshot() (*releaseSnapshot, error) {
+	content, err := impl.blobAdapter.Get(impl.snapshotKey)
+	if err != nil {
+		return nil, err
+	}
+	gzipReader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+	snapshot := &releaseSnapshot{}
+	err = json.NewDecoder(gzipReader).Decode(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
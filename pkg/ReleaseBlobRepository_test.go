@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/pkg/blobStorage"
+	"go.uber.org/zap"
+)
+
+func newTestReleaseBlobRepositoryFactory(t *testing.T) func() *ReleaseBlobRepositoryImpl {
+	logger := zap.NewNop().Sugar()
+	blobAdapter, err := blobStorage.NewFileSystemBlobAdapterImpl(logger, t.TempDir())
+	if err != nil {
+		t.Fatalf("error in creating filesystem blob adapter: %v", err)
+	}
+	releaseChannelClassifier := newTestReleaseChannelClassifier(t)
+	return func() *ReleaseBlobRepositoryImpl {
+		return NewReleaseBlobRepositoryImpl(logger, blobAdapter, NewReleaseStoreImpl(logger), conformanceRepo, true, releaseChannelClassifier)
+	}
+}
+
+func TestReleaseBlobRepositoryWithoutSnapshot(t *testing.T) {
+	newRepository := newTestReleaseBlobRepositoryFactory(t)
+	repository := newRepository()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// missing snapshot hash of a fresh bucket means releases have to be fetched, it is not an error
+	stale, err := repository.IsStale()
+	if err != nil || !stale {
+		t.Fatalf("IsStale of fresh bucket = %v, %v, want true, nil", stale, err)
+	}
+	err = repository.Save(newTestRelease("v1.0.0", base, false))
+	if err != ErrReleasesNotLoaded {
+		t.Fatalf("Save without snapshot = %v, want ErrReleasesNotLoaded", err)
+	}
+	err = repository.Delete("v1.0.0")
+	if err != ErrReleasesNotLoaded {
+		t.Fatalf("Delete without snapshot = %v, want ErrReleasesNotLoaded", err)
+	}
+
+	err = repository.ReplaceAll([]*common.Release{
+		newTestRelease("v1.1.0", base.Add(2*time.Hour), false),
+		newTestRelease("v1.0.0", base.Add(time.Hour), false),
+	})
+	if err != nil {
+		t.Fatalf("ReplaceAll: %v", err)
+	}
+	// replica which has not loaded snapshot yet loads it before saving instead of publishing a single release
+	replica := newRepository()
+	err = replica.Save(newTestRelease("v1.2.0", base.Add(3*time.Hour), false))
+	if err != nil {
+		t.Fatalf("Save by replica with empty store: %v", err)
+	}
+	stale, err = repository.IsStale()
+	if err != nil || stale {
+		t.Fatalf("IsStale after save by replica = %v, %v, want false, nil", stale, err)
+	}
+	assertReleaseTags(t, repository, 0, 0, "v1.2.0", "v1.1.0", "v1.0.0")
+}
+
+// TestReleaseBlobRepositoryConcurrentSave is meant to be run with -race, every save of every replica has to end up
+// in the snapshot
+func TestReleaseBlobRepositoryConcurrentSave(t *testing.T) {
+	newRepository := newTestReleaseBlobRepositoryFactory(t)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := newRepository().ReplaceAll([]*common.Release{newTestRelease("v1.0.0", base, false)})
+	if err != nil {
+		t.Fatalf("ReplaceAll: %v", err)
+	}
+	repository := newRepository()
+	const workers = 4
+	const rounds = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				tagName := fmt.Sprintf("v2.%d.%d", worker, round)
+				err := repository.Save(newTestRelease(tagName, base.Add(time.Duration(worker*rounds+round+1)*time.Minute), false))
+				if err != nil {
+					errs <- fmt.Errorf("Save %s: %w", tagName, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	replica := newRepository()
+	stale, err := replica.IsStale()
+	if err != nil || stale {
+		t.Fatalf("IsStale of replica = %v, %v, want false, nil", stale, err)
+	}
+	releases, err := replica.List(0, 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(releases) != workers*rounds+1 {
+		t.Fatalf("snapshot has %d releases, want %d", len(releases), workers*rounds+1)
+	}
+	if want := repository.getContentHash(); replica.getContentHash() != want {
+		t.Fatalf("content hash of snapshot = %s, want %s", replica.getContentHash(), want)
+	}
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"github.com/devtron-labs/central-api/common"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	headingRegex     = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*$`)
+	boldHeadingRegex = regexp.MustCompile(`^\*\*([^*]+?)\*\*:?$`)
+	listItemRegex    = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+(.*)$`)
+	prNumberRegex    = regexp.MustCompile(`(?:^|[\s(\[])#(\d+)\b|/pull/(\d+)\b`)
+	authorRegex      = regexp.MustCompile(`(?:^|[\s(\[])@([A-Za-z0-9][A-Za-z0-9-]*)`)
+	emojiRegex       = regexp.MustCompile(`:[a-z0-9_+-]+:`)
+)
+
+// sectionKeywords is checked in order, first keyword found as a whole word of the heading decides the section type
+var sectionKeywords = []struct {
+	keywords    []string
+	sectionType common.ReleaseSectionType
+}{
+	{[]string{"breaking"}, common.ReleaseSectionBreakingChanges},
+	{[]string{"feature", "features", "feat", "feats"}, common.ReleaseSectionNewFeatures},
+	{[]string{"enhancement", "enhancements", "improvement", "improvements"}, common.ReleaseSectionEnhancements},
+	{[]string{"bug", "bugs", "bugfix", "bugfixes", "fix", "fixes", "fixed", "hotfix", "hotfixes"}, common.ReleaseSectionBugFixes},
+	{[]string{"doc", "docs", "documentation"}, common.ReleaseSectionDocumentation},
+}
+
+// ParseReleaseBody parses github release markdown into typed sections. Headings (# or bold lines)
+// start a section and list items under them become section items, items found before any
+// heading are put in an OTHERS section. The prerequisites block, from first to last prerequisites
+// marker same as getPrerequisiteContent, is skipped as it is exposed separately. A single marker
+// without its pair does not start a block.
+func ParseReleaseBody(body string) []*common.ReleaseSection {
+	var sections []*common.ReleaseSection
+	var currentSection *common.ReleaseSection
+	var currentItem *common.ReleaseSectionItem
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	prerequisitesStart, prerequisitesEnd := getPrerequisitesBlock(lines)
+	inCodeBlock := false
+	inComment := false
+	for index, rawLine := range lines {
+		line := strings.TrimSpace(rawLine)
+		if index >= prerequisitesStart && index <= prerequisitesEnd || strings.Contains(line, PrerequisitesMatcher) {
+			currentItem = nil
+			continue
+		}
+		if inComment {
+			inComment = !strings.Contains(line, "-->")
+			currentItem = nil
+			continue
+		}
+		if strings.HasPrefix(line, "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock || len(line) == 0 {
+			currentItem = nil
+			continue
+		}
+		if strings.HasPrefix(line, "<!--") {
+			inComment = !strings.Contains(line[len("<!--"):], "-->")
+			currentItem = nil
+			continue
+		}
+		if title, ok := getHeadingTitle(line); ok {
+			currentSection = &common.ReleaseSection{
+				Type:  getSectionType(title),
+				Title: title,
+				Items: []*common.ReleaseSectionItem{},
+			}
+			sections = append(sections, currentSection)
+			currentItem = nil
+			continue
+		}
+		if match := listItemRegex.FindStringSubmatch(line); match != nil {
+			if currentSection == nil {
+				currentSection = &common.ReleaseSection{
+					Type:  common.ReleaseSectionOthers,
+					Items: []*common.ReleaseSectionItem{},
+				}
+				sections = append(sections, currentSection)
+			}
+			currentItem = &common.ReleaseSectionItem{Text: match[1]}
+			currentSection.Items = append(currentSection.Items, currentItem)
+			continue
+		}
+		// indented lines continue the previous item, everything else is free text and is ignored
+		if currentItem != nil && rawLine != line {
+			currentItem.Text = currentItem.Text + " " + line
+		}
+	}
+	parsedSections := make([]*common.ReleaseSection, 0, len(sections))
+	for _, section := range sections {
+		if len(section.Items) == 0 {
+			continue
+		}
+		for _, item := range section.Items {
+			item.PrNumbers = getPrNumbers(item.Text)
+			item.Authors = getAuthors(item.Text)
+		}
+		parsedSections = append(parsedSections, section)
+	}
+	return parsedSections
+}
+
+// getPrerequisitesBlock returns indexes of first and last line with prerequisites marker, end is before start
+// when body has no pair of markers
+func getPrerequisitesBlock(lines []string) (int, int) {
+	start, end := -1, -1
+	for index, line := range lines {
+		if !strings.Contains(line, PrerequisitesMatcher) {
+			continue
+		}
+		if start < 0 {
+			start = index
+		}
+		end = index
+	}
+	if start == end {
+		return 0, -1
+	}
+	return start, end
+}
+
+func getHeadingTitle(line string) (string, bool) {
+	var title string
+	if match := headingRegex.FindStringSubmatch(line); match != nil {
+		title = match[1]
+	} else if match := boldHeadingRegex.FindStringSubmatch(line); match != nil {
+		title = match[1]
+	} else {
+		return "", false
+	}
+	title = emojiRegex.ReplaceAllString(title, "")
+	title = strings.Trim(title, "*_: \t")
+	return title, true
+}
+
+// getSectionType matches whole words only, so that e.g. "Debugging" or "Docker" do not decide the type
+func getSectionType(title string) common.ReleaseSectionType {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[word] = true
+	}
+	for _, sectionKeyword := range sectionKeywords {
+		for _, keyword := range sectionKeyword.keywords {
+			if words[keyword] {
+				return sectionKeyword.sectionType
+			}
+		}
+	}
+	return common.ReleaseSectionOthers
+}
+
+func getPrNumbers(text string) []int {
+	var prNumbers []int
+	seen := make(map[int]bool)
+	for _, match := range prNumberRegex.FindAllStringSubmatch(text, -1) {
+		numberString := match[1]
+		if len(numberString) == 0 {
+			numberString = match[2]
+		}
+		number, err := strconv.Atoi(numberString)
+		if err != nil || seen[number] {
+			continue
+		}
+		seen[number] = true
+		prNumbers = append(prNumbers, number)
+	}
+	return prNumbers
+}
+
+func getAuthors(text string) []string {
+	var authors []string
+	seen := make(map[string]bool)
+	for _, match := range authorRegex.FindAllStringSubmatch(text, -1) {
+		author := match[1]
+		if seen[author] {
+			continue
+		}
+		seen[author] = true
+		authors = append(authors, author)
+	}
+	return authors
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/devtron-labs/central-api/common"
+)
+
+func TestGetSectionType(t *testing.T) {
+	tests := []struct {
+		title string
+		want  common.ReleaseSectionType
+	}{
+		{title: "Bugs", want: common.ReleaseSectionBugFixes},
+		{title: "Bug Fixes", want: common.ReleaseSectionBugFixes},
+		{title: "Hotfixes:", want: common.ReleaseSectionBugFixes},
+		{title: "New Features", want: common.ReleaseSectionNewFeatures},
+		{title: "feat/ui", want: common.ReleaseSectionNewFeatures},
+		{title: "Enhancements", want: common.ReleaseSectionEnhancements},
+		{title: "Performance improvements", want: common.ReleaseSectionEnhancements},
+		{title: "Breaking Changes", want: common.ReleaseSectionBreakingChanges},
+		{title: "Breaking fixes", want: common.ReleaseSectionBreakingChanges},
+		{title: "Docs", want: common.ReleaseSectionDocumentation},
+		{title: "Documentation", want: common.ReleaseSectionDocumentation},
+		{title: "Debugging", want: common.ReleaseSectionOthers},
+		{title: "Docker images", want: common.ReleaseSectionOthers},
+		{title: "Prefix changes", want: common.ReleaseSectionOthers},
+		{title: "Featured contributors", want: common.ReleaseSectionOthers},
+		{title: "Others", want: common.ReleaseSectionOthers},
+	}
+	for _, tt := range tests {
+		if got := getSectionType(tt.title); got != tt.want {
+			t.Errorf("getSectionType(%q) = %s, want %s", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestParseReleaseBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		// want lists sections as "<type> <title>: <item>|<item>"
+		want []string
+	}{
+		{
+			name: "sections",
+			body: "## Bug Fixes\r\n- fix login (#12) @alice\r\n  continued line\r\n\r\n**Enhancements**\n* faster sync\n\nfree text\n# Docs\n",
+			want: []string{"BUG_FIXES Bug Fixes: fix login (#12) @alice continued line", "ENHANCEMENTS Enhancements: faster sync"},
+		},
+		{
+			name: "items before heading",
+			body: "- first\n## Features\n- second",
+			want: []string{"OTHERS : first", "NEW_FEATURES Features: second"},
+		},
+		{
+			name: "prerequisites block",
+			body: "<!--upgrade-prerequisites-required-->\n- run migration\n<!--upgrade-prerequisites-required-->\n## Bugs\n- fixed crash",
+			want: []string{"BUG_FIXES Bugs: fixed crash"},
+		},
+		{
+			name: "unmatched prerequisites marker",
+			body: "## Features\n- new ui\n<!--upgrade-prerequisites-required-->\n## Bugs\n- fixed crash",
+			want: []string{"NEW_FEATURES Features: new ui", "BUG_FIXES Bugs: fixed crash"},
+		},
+		{
+			name: "fenced code block",
+			body: "## Features\n```\n- not an item\n## not a heading\n```\n- new ui",
+			want: []string{"NEW_FEATURES Features: new ui"},
+		},
+		{
+			name: "html comments",
+			body: "<!-- single line -->\n## Features\n<!--\n- hidden item\n## Hidden\n-->\n- new ui\n<!-- spans\nlines -->\n- second",
+			want: []string{"NEW_FEATURES Features: new ui|second"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, section := range ParseReleaseBody(tt.body) {
+				items := make([]string, 0, len(section.Items))
+				for _, item := range section.Items {
+					items = append(items, item.Text)
+				}
+				got = append(got, string(section.Type)+" "+section.Title+": "+strings.Join(items, "|"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReleaseBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"fmt"
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/internal/semver"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// UnknownChannelError is returned when requested channel is not one of the configured channels
+type UnknownChannelError struct {
+	Channel  string
+	Channels []string
+}
+
+func (e *UnknownChannelError) Error() string {
+	return fmt.Sprintf("unknown channel %s, configured channels are %s", e.Channel, strings.Join(e.Channels, ", "))
+}
+
+// ReleaseChannelClassifier puts releases in channels, see util.ReleaseChannelConfig. Channel is derived on every read
+// so that rule changes apply to stored releases without fetching them again.
+type ReleaseChannelClassifier interface {
+	// Channels returns configured channels most stable first
+	Channels() []string
+	// Resolve returns default channel for empty channel, *UnknownChannelError if channel is not configured
+	Resolve(channel string) (string, error)
+	// Classify returns channel of release
+	Classify(release *common.Release) string
+	// Filter returns copies of releases served in feed of channel with Channel set, order is kept
+	Filter(releases []*common.Release, channel string) []*common.Release
+}
+
+type ReleaseChannelClassifierImpl struct {
+	logger        *zap.SugaredLogger
+	config        *util.ReleaseChannelConfig
+	rules         []*util.ReleaseChannelRule
+	channelOrders map[string]int
+}
+
+func NewReleaseChannelClassifierImpl(logger *zap.SugaredLogger, config *util.ReleaseChannelConfig) (*ReleaseChannelClassifierImpl, error) {
+	rules, err := config.GetRules()
+	if err != nil {
+		return nil, err
+	}
+	channelOrders := make(map[string]int, len(config.Channels))
+	for i, channel := range config.Channels {
+		channelOrders[channel] = i
+	}
+	return &ReleaseChannelClassifierImpl{
+		logger:        logger,
+		config:        config,
+		rules:         rules,
+		channelOrders: channelOrders,
+	}, nil
+}
+
+func (impl *ReleaseChannelClassifierImpl) Channels() []string {
+	return impl.config.Channels
+}
+
+func (impl *ReleaseChannelClassifierImpl) Resolve(channel string) (string, error) {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	if len(channel) == 0 {
+		return impl.config.DefaultChannel, nil
+	}
+	if _, ok := impl.channelOrders[channel]; !ok {
+		return "", &UnknownChannelError{Channel: channel, Channels: impl.config.Channels}
+	}
+	return channel, nil
+}
+
+// Classify matches first identifier of semver pre-release suffix with rules, releases having a suffix which no
+// rule matches or marked prerelease on release source go to prerelease channel
+func (impl *ReleaseChannelClassifierImpl) Classify(release *common.Release) string {
+	version, err := semver.Parse(release.TagName)
+	if err == nil && version.IsPreRelease() {
+		identifier := strings.ToLower(version.PreRelease[0])
+		for _, rule := range impl.rules {
+			for _, prefix := range rule.Prefixes {
+				if strings.HasPrefix(identifier, prefix) {
+					return rule.Channel
+				}
+			}
+		}
+		return impl.config.PrereleaseChannel
+	}
+	if release.Prerelease {
+		return impl.config.PrereleaseChannel
+	}
+	return impl.config.Channels[0]
+}
+
+func (impl *ReleaseChannelClassifierImpl) Filter(releases []*common.Release, channel string) []*common.Release {
+	feedOrder := impl.channelOrders[channel]
+	channelReleases := make([]*common.Release, 0, len(releases))
+	for _, release := range releases {
+		releaseChannel := impl.Classify(release)
+		if impl.channelOrders[releaseChannel] > feedOrder {
+			continue
+		}
+		// releases are shared with store, channel is set on a copy
+		channelRelease := *release
+		channelRelease.Channel = releaseChannel
+		channelReleases = append(channelReleases, &channelRelease)
+	}
+	return channelReleases
+}
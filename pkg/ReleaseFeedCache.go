@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"github.com/devtron-labs/central-api/common"
+	"sync"
+)
+
+// releaseFeedCache holds published releases of a release repository version and channel feeds built from them,
+// so that releases are listed and classified again only when the version changes. Cached lists are shared between
+// requests and must never be modified.
+type releaseFeedCache struct {
+	version  string
+	releases []*common.Release
+	mutex    sync.Mutex
+	feeds    map[string][]*common.Release
+}
+
+func newReleaseFeedCache(version string, releases []*common.Release) *releaseFeedCache {
+	return &releaseFeedCache{
+		version:  version,
+		releases: releases,
+		feeds:    make(map[string][]*common.Release),
+	}
+}
+
+// getFeed returns releases in feed of a resolved channel, feed is classified on first use
+func (cache *releaseFeedCache) getFeed(channel string, releaseChannelClassifier ReleaseChannelClassifier) []*common.Release {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	feed, ok := cache.feeds[channel]
+	if !ok {
+		feed = releaseChannelClassifier.Filter(cache.releases, channel)
+		cache.feeds[channel] = feed
+	}
+	return feed[:len(feed):len(feed)]
+}
+
+// releaseFeedCaches keeps a feed cache per key, a cache is replaced when version of its key changes
+type releaseFeedCaches struct {
+	lock   sync.RWMutex
+	caches map[string]*releaseFeedCache
+}
+
+func newReleaseFeedCaches() *releaseFeedCaches {
+	return &releaseFeedCaches{caches: make(map[string]*releaseFeedCache)}
+}
+
+// get returns cache of key if it was built for version, listReleases is called to build it otherwise
+func (impl *releaseFeedCaches) get(key string, version string, listReleases func() ([]*common.Release, error)) (*releaseFeedCache, error) {
+	impl.lock.RLock()
+	cache := impl.caches[key]
+	impl.lock.RUnlock()
+	if cache != nil && cache.version == version {
+		return cache, nil
+	}
+	releases, err := listReleases()
+	if err != nil {
+		return nil, err
+	}
+	cache = newReleaseFeedCache(version, releases)
+	impl.lock.Lock()
+	impl.caches[key] = cache
+	impl.lock.Unlock()
+	return cache, nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/internal/markdown"
+	"go.uber.org/zap"
+)
+
+const (
+	ReleaseFormatMarkdown = "markdown"
+	ReleaseFormatHtml     = "html"
+	ReleaseFormatText     = "text"
+)
+
+func IsValidReleaseFormat(format string) bool {
+	return format == ReleaseFormatMarkdown || format == ReleaseFormatHtml || format == ReleaseFormatText
+}
+
+type ReleaseNoteRenderService interface {
+	// Render returns copies of releases with Body and PrerequisiteMessage rendered in format
+	Render(releases []*common.Release, format string) ([]*common.Release, error)
+}
+
+type ReleaseNoteRenderServiceImpl struct {
+	logger   *zap.SugaredLogger
+	renderer *markdown.Renderer
+	// repoRenderers link pr numbers to issues of release's own repo, renderer is used for other releases
+	repoRenderers map[string]*markdown.Renderer
+}
+
+func NewReleaseNoteRenderServiceImpl(logger *zap.SugaredLogger, releaseSource ReleaseSource) *ReleaseNoteRenderServiceImpl {
+	impl := &ReleaseNoteRenderServiceImpl{
+		logger:        logger,
+		renderer:      markdown.NewRenderer(""),
+		repoRenderers: make(map[string]*markdown.Renderer),
+	}
+	for i, repo := range releaseSource.Repos() {
+		issueBaseUrl := releaseSource.IssueBaseUrl(repo)
+		if len(issueBaseUrl) == 0 {
+			continue
+		}
+		impl.repoRenderers[repo] = markdown.NewRenderer(issueBaseUrl)
+		if i == 0 {
+			impl.renderer = impl.repoRenderers[repo]
+		}
+	}
+	return impl
+}
+
+func (impl *ReleaseNoteRenderServiceImpl) Render(releases []*common.Release, format string) ([]*common.Release, error) {
+	switch format {
+	case ReleaseFormatMarkdown, "":
+		return releases, nil
+	case ReleaseFormatHtml, ReleaseFormatText:
+	default:
+		return nil, fmt.Errorf("unsupported release format %s", format)
+	}
+	renderedReleases := make([]*common.Release, 0, len(releases))
+	for _, release := range releases {
+		renderer, ok := impl.repoRenderers[release.Repo]
+		if !ok {
+			renderer = impl.renderer
+		}
+		render := renderer.RenderHTML
+		if format == ReleaseFormatText {
+			render = renderer.RenderText
+		}
+		// releases are shared with cache, never modified in place
+		renderedRelease := *release
+		renderedRelease.Body = render(release.Body)
+		renderedRelease.PrerequisiteMessage = render(release.PrerequisiteMessage)
+		renderedReleases = append(renderedReleases, &renderedRelease)
+	}
+	return renderedReleases, nil
+}
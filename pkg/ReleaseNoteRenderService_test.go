@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/common"
+	"go.uber.org/zap"
+)
+
+func TestRenderLinksIssuesOfReleaseSource(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	tests := []struct {
+		name          string
+		releaseSource ReleaseSource
+		repo          string
+		wantLink      string
+	}{
+		{
+			name: "github",
+			releaseSource: NewGitHubReleaseSourceImpl(logger, &util.GitHubClient{GitHubConfig: &util.GitHubConfig{
+				GitHubHost: "https://github.example.com/", GitHubOrg: "devtron-labs", GitHubRepo: "devtron",
+			}}, nil),
+			repo:     "devtron-labs/devtron",
+			wantLink: `href="https://github.example.com/devtron-labs/devtron/issues/12"`,
+		},
+		{
+			name: "gitlab",
+			releaseSource: NewGitLabReleaseSourceImpl(logger, &util.ReleaseSourceConfig{
+				GitLabHost: "https://gitlab.example.com", GitLabProject: "devtron-labs/devtron",
+			}),
+			repo:     "devtron-labs/devtron",
+			wantLink: `href="https://gitlab.example.com/devtron-labs/devtron/-/issues/12"`,
+		},
+		{
+			name: "gitlab project id",
+			releaseSource: NewGitLabReleaseSourceImpl(logger, &util.ReleaseSourceConfig{
+				GitLabHost: "https://gitlab.example.com", GitLabProject: "278964",
+			}),
+			repo: "278964",
+		},
+		{
+			name: "gitea",
+			releaseSource: NewGiteaReleaseSourceImpl(logger, &util.ReleaseSourceConfig{
+				GiteaHost: "https://gitea.example.com", GiteaOwner: "devtron-labs", GiteaRepo: "devtron",
+			}),
+			repo:     "devtron-labs/devtron",
+			wantLink: `href="https://gitea.example.com/devtron-labs/devtron/issues/12"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releaseNoteRenderService := NewReleaseNoteRenderServiceImpl(logger, tt.releaseSource)
+			releases, err := releaseNoteRenderService.Render([]*common.Release{{Repo: tt.repo, Body: "- fix login #12"}}, ReleaseFormatHtml)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			body := releases[0].Body
+			if len(tt.wantLink) == 0 {
+				if strings.Contains(body, "href=") {
+					t.Errorf("Render() = %s, want no issue link", body)
+				}
+				return
+			}
+			if !strings.Contains(body, tt.wantLink) {
+				t.Errorf("Render() = %s, want link %s", body, tt.wantLink)
+			}
+		})
+	}
+}
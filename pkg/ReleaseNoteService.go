@@ -17,346 +17,427 @@
 package pkg
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
 	util "github.com/devtron-labs/central-api/client"
 	"github.com/devtron-labs/central-api/common"
-	"github.com/devtron-labs/central-api/pkg/releaseNote"
-	blob_storage "github.com/devtron-labs/common-lib/blob-storage"
-	"github.com/go-pg/pg"
+	"github.com/devtron-labs/central-api/internal/semver"
 	"github.com/google/go-github/github"
 	"go.uber.org/zap"
-	"os"
+	"sort"
 	"strings"
-	"sync"
 	"time"
 )
 
 type ReleaseNoteService interface {
 	GetModules() ([]*common.Module, error)
-	GetReleases() ([]*common.Release, error)
+	// GetReleases returns releases of repo in feed of channel, empty repo is the primary repo and empty channel is the
+	// default channel. Returns *UnknownRepoError or *UnknownChannelError if they are not configured. Returned releases
+	// are cached and shared between callers, they must not be modified.
+	GetReleases(repo string, channel string, offset int, size int) ([]*common.Release, error)
+	GetReleasesV2(repo string, channel string, offset int, size int) ([]*common.ReleaseV2, error)
+	// GetCombinedReleases returns releases of every configured repo in feed of channel latest published first
+	GetCombinedReleases(channel string, offset int, size int) ([]*common.Release, error)
+	// GetLatestRelease returns latest release of repo in feed of channel, nil if there is none
+	GetLatestRelease(repo string, channel string) (*common.Release, error)
+	GetReleasesInRange(from string, to string) (*common.ReleaseRange, error)
 	UpdateReleases(requestBodyBytes []byte) (bool, error)
 	GetModulesV2() ([]*common.Module, error)
 	GetModuleByName(name string) (*common.Module, error)
 	GetReleasesOnInitialisation()
+	// GetReleasesFromSourceWithRetry fetches every release of repo including drafts, stored releases are not touched
+	GetReleasesFromSourceWithRetry(repo string) ([]*common.Release, error)
+}
+
+// UnknownRepoError is returned when requested repo is not one of the configured repos
+type UnknownRepoError struct {
+	Repo  string
+	Repos []string
+}
+
+func (e *UnknownRepoError) Error() string {
+	return fmt.Sprintf("unknown repo %s, configured repos are %s", e.Repo, strings.Join(e.Repos, ", "))
+}
+
+// InvalidVersionError is returned for version query params which are missing, not semver or not in order
+type InvalidVersionError struct {
+	Param   string
+	Message string
+}
+
+func (e *InvalidVersionError) Error() string {
+	return fmt.Sprintf("invalid %s, %s", e.Param, e.Message)
 }
 
 type ReleaseNoteServiceImpl struct {
-	logger                *zap.SugaredLogger
-	client                *util.GitHubClient
-	mutex                 sync.Mutex
-	moduleConfig          *util.ModuleConfig
-	releaseNoteRepository releaseNote.ReleaseNoteRepository
-	blobConfig            *util.BlobConfigVariables
-	blobStorageService    *blob_storage.BlobStorageServiceImpl
+	logger                    *zap.SugaredLogger
+	client                    *util.GitHubClient
+	moduleConfig              *util.ModuleConfig
+	releaseRepositoryRegistry ReleaseRepositoryRegistry
+	releaseSource             ReleaseSource
+	releaseChannelClassifier  ReleaseChannelClassifier
+	// releaseFeedCaches is keyed by repo, combinedReleaseFeedKey holds feeds of every repo
+	releaseFeedCaches *releaseFeedCaches
 }
 
-func NewReleaseNoteServiceImpl(logger *zap.SugaredLogger, client *util.GitHubClient,
-	moduleConfig *util.ModuleConfig, blobConfig *util.BlobConfigVariables, blobStorageService *blob_storage.BlobStorageServiceImpl) (*ReleaseNoteServiceImpl, error) {
-	var releaseNoteRepository releaseNote.ReleaseNoteRepository
-	var err error
-	if !blobConfig.CloudConfigured {
-		releaseNoteRepository, err = releaseNote.NewReleaseNoteRepositoryImpl(logger)
-		if err != nil {
-			return nil, err
-		}
-	}
+// combinedReleaseFeedKey can not collide with owner/repo names
+const combinedReleaseFeedKey = "*"
+
+func NewReleaseNoteServiceImpl(logger *zap.SugaredLogger, client *util.GitHubClient, moduleConfig *util.ModuleConfig,
+	releaseRepositoryRegistry ReleaseRepositoryRegistry, releaseSource ReleaseSource, releaseChannelClassifier ReleaseChannelClassifier) *ReleaseNoteServiceImpl {
 	serviceImpl := &ReleaseNoteServiceImpl{
-		logger:                logger,
-		client:                client,
-		moduleConfig:          moduleConfig,
-		releaseNoteRepository: releaseNoteRepository,
-		blobConfig:            blobConfig,
-		blobStorageService:    blobStorageService,
-	}
-	// Async Call for getting releases from Github
-	serviceImpl.logger.Infow("getting release from github")
+		logger:                    logger,
+		client:                    client,
+		moduleConfig:              moduleConfig,
+		releaseRepositoryRegistry: releaseRepositoryRegistry,
+		releaseSource:             releaseSource,
+		releaseChannelClassifier:  releaseChannelClassifier,
+		releaseFeedCaches:         newReleaseFeedCaches(),
+	}
+	// Async Call for getting releases from release source
+	serviceImpl.logger.Infow("getting release from release source")
 	go serviceImpl.GetReleasesOnInitialisation()
-	return serviceImpl, nil
+	return serviceImpl
 }
 
 const ActionPublished = "published"
 const ActionEdited = "edited"
+const ActionCreated = "created"
+const ActionDeleted = "deleted"
+const ActionUnpublished = "unpublished"
+const ActionPrereleased = "prereleased"
+const ActionReleased = "released"
 const EventTypeRelease = "release"
 const TimeFormatLayout = "2006-01-02T15:04:05Z"
-const TagLink = "https://github.com/devtron-labs/devtron/releases/tag"
 const PrerequisitesMatcher = "<!--upgrade-prerequisites-required-->"
-const CACHE_KEY = "latest"
-const LATEST_FILENAME = CACHE_KEY + ".txt"                      // TODO:Will Remove this before merging
-const BLOB_LATEST_RELEASE_FILE_NAME = "/tmp/" + LATEST_FILENAME // TODO:Have to change it to "/latest.txt"
+const MaxReleasesPerPage = 100
 
-var releaseCache = make(map[string][]*common.Release)
+func isHandledReleaseAction(action string) bool {
+	switch action {
+	case ActionPublished, ActionEdited, ActionCreated, ActionDeleted, ActionUnpublished, ActionPrereleased, ActionReleased:
+		return true
+	}
+	return false
+}
 
+// UpdateReleases applies a release webhook event of configured release source to release repository of event's repo.
+// Deleted releases are removed, unpublished releases are kept as draft and drafts are never served. Every other
+// action upserts the release. Events of repos which are not configured are ignored.
 func (impl *ReleaseNoteServiceImpl) UpdateReleases(requestBodyBytes []byte) (bool, error) {
-	data := make(map[string]interface{})
-	err := json.Unmarshal(requestBodyBytes, &data)
+	releaseEvent, err := impl.releaseSource.DecodeReleaseEvent(requestBodyBytes)
 	if err != nil {
-		impl.logger.Errorw("unmarshal error", "err", err)
+		impl.logger.Errorw("invalid release webhook payload", "err", err)
 		return false, err
 	}
-	action := data["action"].(string)
-	if action != ActionPublished && action != ActionEdited {
-		impl.logger.Warnw("handling only published and edited action, ignored other actions", "action", action)
+	action := releaseEvent.Action
+	if !isHandledReleaseAction(action) {
+		impl.logger.Warnw("ignored unsupported release action", "action", action)
 		return false, nil
 	}
-	releaseData := data["release"].(map[string]interface{})
-	releaseName := releaseData["name"].(string)
-	tagName := releaseData["tag_name"].(string)
-	createdAtString := releaseData["created_at"].(string)
-	createdAt, error := time.Parse(TimeFormatLayout, createdAtString)
-	if error != nil {
-		impl.logger.Errorw("error on time parsing, ignored this key", "err", error)
-		//return false, nil
-	}
-	publishedAtString := releaseData["published_at"].(string)
-	publishedAt, error := time.Parse(TimeFormatLayout, publishedAtString)
-	if error != nil {
-		impl.logger.Errorw("error on time parsing, ignored this key", "err", error)
-		//return false, nil
-	}
-	body := releaseData["body"].(string)
-	releaseInfo := &common.Release{
-		TagName:     tagName,
-		ReleaseName: releaseName,
-		Body:        body,
-		CreatedAt:   createdAt,
-		PublishedAt: publishedAt,
-		TagLink:     fmt.Sprintf("%s/%s", TagLink, tagName),
-	}
-	impl.getPrerequisiteContent(releaseInfo)
-
-	//updating cache, fetch existing object and append new item
-	var releaseList []*common.Release
-	var releaseNotes []*common.Release
-	if impl.blobConfig.CloudConfigured {
-		releaseNotes = releaseCache[CACHE_KEY]
-	} else {
-		releaseNoteObj, err := impl.getActiveReleaseNote()
-		if err != nil {
-			impl.logger.Errorw("error in getting release notes from DB", "err", err)
-			return false, err
-		}
-		releaseNotes = releaseNoteObj.ReleaseNote
+	repo, ok := impl.releaseRepositoryRegistry.Resolve(releaseEvent.Repo)
+	if !ok {
+		impl.logger.Warnw("ignored release event of repo which is not configured", "repo", releaseEvent.Repo, "tagName", releaseEvent.Release.TagName)
+		return false, nil
 	}
-	//releaseList = append(releaseList, releaseInfo)
-
-	if len(releaseNotes) > 0 {
-		releaseList = append(releaseList, releaseNotes...)
+	// stored releases are brought up to date first like on read, so that a change is never applied to a partial list
+	releaseRepository, err := impl.getFreshReleaseRepository(repo)
+	if err != nil {
+		impl.logger.Errorw("error in refreshing releases before applying release event", "repo", repo, "tagName", releaseEvent.Release.TagName, "err", err)
+		return false, err
 	}
-
-	isNew := true
-	for _, release := range releaseList {
-		// tag is mandatory while drafting a new release
-		if release.TagName == releaseInfo.TagName {
-			release.ReleaseName = releaseInfo.ReleaseName
-			release.Body = releaseInfo.Body
-			isNew = false
-		}
+	releaseInfo := impl.enrichRelease(releaseEvent.Release)
+	releaseInfo.Repo = repo
+	// published_at is null for drafts and created_at may be missing in hand crafted payloads
+	if releaseInfo.CreatedAt.IsZero() {
+		releaseInfo.CreatedAt = releaseInfo.PublishedAt
+	}
+	if releaseInfo.PublishedAt.IsZero() && !releaseInfo.Draft {
+		impl.logger.Warnw("published_at missing in release payload, using created_at", "tagName", releaseInfo.TagName)
+		releaseInfo.PublishedAt = releaseInfo.CreatedAt
 	}
-	if isNew {
-		releaseList = append([]*common.Release{releaseInfo}, releaseList...)
+	if action == ActionUnpublished {
+		releaseInfo.Draft = true
 	}
-	if impl.blobConfig.CloudConfigured {
-		releaseCache[CACHE_KEY] = releaseList
-		return impl.updateTagToBlobStorage(releaseInfo)
-	} else {
-		impl.mutex.Lock()
-		defer impl.mutex.Unlock()
-		impl.updateReleaseNotesInDb(releaseList, true)
-		return true, nil
+	if action == ActionDeleted {
+		return impl.removeRelease(releaseRepository, repo, releaseInfo.TagName)
 	}
-	return true, err
+	return impl.saveRelease(releaseRepository, releaseInfo)
 }
 
-func (impl *ReleaseNoteServiceImpl) updateTagToBlobStorage(releaseInfo *common.Release) (bool, error) {
-	artifactUploaded := false
-	err := impl.createFileAndUpdateDataForBlob(releaseInfo.TagName)
+func (impl *ReleaseNoteServiceImpl) saveRelease(releaseRepository ReleaseRepository, releaseInfo *common.Release) (bool, error) {
+	err := releaseRepository.Save(releaseInfo)
 	if err != nil {
-		return artifactUploaded, err
+		impl.logger.Errorw("error in saving release", "repo", releaseInfo.Repo, "tagName", releaseInfo.TagName, "err", err)
+		return false, err
 	}
-	request := impl.createBlobStorageRequest(impl.blobConfig.BlobStorageType, BLOB_LATEST_RELEASE_FILE_NAME, LATEST_FILENAME)
-	err = impl.blobStorageService.UploadToBlobWithSession(request)
+	return true, nil
+}
+
+func (impl *ReleaseNoteServiceImpl) removeRelease(releaseRepository ReleaseRepository, repo string, tagName string) (bool, error) {
+	err := releaseRepository.Delete(tagName)
 	if err != nil {
-		return artifactUploaded, err
+		impl.logger.Errorw("error in deleting release", "repo", repo, "tagName", tagName, "err", err)
+		return false, err
 	}
-	artifactUploaded = true
-	return artifactUploaded, err
+	return true, nil
 }
 
-func (impl *ReleaseNoteServiceImpl) createFileAndUpdateDataForBlob(tagName string) error {
-	file, err := os.Create(BLOB_LATEST_RELEASE_FILE_NAME)
-	defer file.Close()
+// enrichRelease fills fields derived from release body, release sources only map provider fields
+func (impl *ReleaseNoteServiceImpl) enrichRelease(release *common.Release) *common.Release {
+	impl.getPrerequisiteContent(release)
+	release.Sections = ParseReleaseBody(release.Body)
+	return release
+}
+
+// GetReleases returns releases latest first, size <= 0 returns all the releases after offset
+func (impl *ReleaseNoteServiceImpl) GetReleases(repo string, channel string, offset int, size int) ([]*common.Release, error) {
+	channel, err := impl.releaseChannelClassifier.Resolve(channel)
 	if err != nil {
-		impl.logger.Errorw("error in creating file", "err", err)
-		return err
+		return nil, err
 	}
-	data := []byte(tagName)
-	_, err = file.Write(data)
-
+	releaseFeedCache, err := impl.getRepoReleaseFeedCache(repo)
 	if err != nil {
-		impl.logger.Errorw("error in writing file", "tagName", tagName, "err", err)
-		return err
+		return nil, err
 	}
-	return err
+	// channel is derived while reading, so pagination happens after filtering
+	return paginateReleases(releaseFeedCache.getFeed(channel, impl.releaseChannelClassifier), offset, size), nil
 }
 
-func (impl *ReleaseNoteServiceImpl) GetReleasesFromGithub() ([]*common.Release, bool) {
-	operationComplete := false
-	var releasesDto []*common.Release
-	releases, _, err := impl.client.GitHubClient.Repositories.ListReleases(context.Background(), impl.client.GitHubConfig.GitHubOrg, impl.client.GitHubConfig.GitHubRepo, &github.ListOptions{})
+func (impl *ReleaseNoteServiceImpl) GetLatestRelease(repo string, channel string) (*common.Release, error) {
+	releaseList, err := impl.GetReleases(repo, channel, 0, 1)
+	if err != nil || len(releaseList) == 0 {
+		return nil, err
+	}
+	return releaseList[0], nil
+}
+
+// getRepoReleaseFeedCache returns feed cache of every published release of repo, releases are fetched from release
+// source if stored ones are stale and listed again only if release repository version changed
+func (impl *ReleaseNoteServiceImpl) getRepoReleaseFeedCache(repo string) (*releaseFeedCache, error) {
+	resolvedRepo, ok := impl.releaseRepositoryRegistry.Resolve(repo)
+	if !ok {
+		return nil, &UnknownRepoError{Repo: repo, Repos: impl.releaseRepositoryRegistry.Repos()}
+	}
+	releaseRepository, err := impl.getFreshReleaseRepository(resolvedRepo)
 	if err != nil {
-		responseErr, ok := err.(*github.ErrorResponse)
-		if !ok || responseErr.Response.StatusCode != 404 {
-			impl.logger.Errorw("error in fetching releases from github", "err", err, "config", "config")
-			//todo - any specific message
-			return releasesDto, operationComplete
-		} else {
-			impl.logger.Errorw("error in fetching releases from github", "err", err)
-			return releasesDto, operationComplete
-		}
+		return nil, err
 	}
-	if err == nil {
-		operationComplete = true
+	version, err := releaseRepository.Version()
+	if err != nil {
+		return nil, err
 	}
+	return impl.releaseFeedCaches.get(resolvedRepo, version, func() ([]*common.Release, error) {
+		return releaseRepository.List(0, 0)
+	})
+}
 
-	for _, item := range releases {
-		if item == nil {
-			impl.logger.Warnw("error while getting release from repository", "err", err)
-			continue
-		}
-		var tagName, releaseName, body, tagLink string
-		var createdAt, publishedAt time.Time
-		if item.TagName != nil {
-			tagName = *item.TagName
-		}
-		if item.Name != nil {
-			releaseName = *item.Name
-		}
-		if item.Body != nil {
-			body = *item.Body
-		}
-		if item.TagName != nil {
-			tagLink = fmt.Sprintf("%s/%s", TagLink, *item.TagName)
-		}
-		if item.CreatedAt != nil {
-			createdAt = item.CreatedAt.Time
+func (impl *ReleaseNoteServiceImpl) GetCombinedReleases(channel string, offset int, size int) ([]*common.Release, error) {
+	channel, err := impl.releaseChannelClassifier.Resolve(channel)
+	if err != nil {
+		return nil, err
+	}
+	repos := impl.releaseRepositoryRegistry.Repos()
+	repoFeedCaches := make([]*releaseFeedCache, 0, len(repos))
+	versions := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		repoFeedCache, err := impl.getRepoReleaseFeedCache(repo)
+		if err != nil {
+			return nil, err
 		}
-		if item.PublishedAt != nil {
-			publishedAt = item.PublishedAt.Time
+		repoFeedCaches = append(repoFeedCaches, repoFeedCache)
+		versions = append(versions, repoFeedCache.version)
+	}
+	// combined feed is built again when version of any repo changes
+	combinedFeedCache, err := impl.releaseFeedCaches.get(combinedReleaseFeedKey, strings.Join(versions, ","), func() ([]*common.Release, error) {
+		var releaseList []*common.Release
+		for _, repoFeedCache := range repoFeedCaches {
+			releaseList = append(releaseList, repoFeedCache.releases...)
 		}
-		dto := &common.Release{
-			TagName:     tagName,
-			ReleaseName: releaseName,
-			CreatedAt:   createdAt,
-			PublishedAt: publishedAt,
-			Body:        body,
-			TagLink:     tagLink,
+		// releases of a repo are already ordered, stable sort keeps their order on equal publish time
+		sort.SliceStable(releaseList, func(i, j int) bool {
+			return releaseList[i].PublishedAt.After(releaseList[j].PublishedAt)
+		})
+		return releaseList, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paginateReleases(combinedFeedCache.getFeed(channel, impl.releaseChannelClassifier), offset, size), nil
+}
+
+// getFreshReleaseRepository returns release repository of resolved repo after refreshing it if it is stale
+func (impl *ReleaseNoteServiceImpl) getFreshReleaseRepository(repo string) (ReleaseRepository, error) {
+	releaseRepository := impl.releaseRepositoryRegistry.Get(repo)
+	stale, err := releaseRepository.IsStale()
+	if err != nil {
+		return nil, err
+	}
+	if stale {
+		err = impl.refreshReleasesFromSource(repo, releaseRepository)
+		if err != nil {
+			return nil, err
 		}
-		impl.getPrerequisiteContent(dto)
-		releasesDto = append(releasesDto, dto)
 	}
+	return releaseRepository, nil
+}
+
+// refreshReleasesFromSource replaces stored releases of repo with the ones fetched from release source
+func (impl *ReleaseNoteServiceImpl) refreshReleasesFromSource(repo string, releaseRepository ReleaseRepository) error {
+	releaseList, err := impl.GetReleasesFromSourceWithRetry(repo)
+	if err != nil {
+		return err
+	}
+	if len(releaseList) == 0 {
+		return nil
+	}
+	err = releaseRepository.ReplaceAll(releaseList)
+	if err != nil {
+		impl.logger.Errorw("error in storing releases fetched from release source", "repo", repo, "err", err)
+		return err
+	}
+	return nil
+}
 
-	return releasesDto, operationComplete
+// GetReleasesV2 returns releases of GetReleases with prerequisite and parsed sections of body
+func (impl *ReleaseNoteServiceImpl) GetReleasesV2(repo string, channel string, offset int, size int) ([]*common.ReleaseV2, error) {
+	releases, err := impl.GetReleases(repo, channel, offset, size)
+	if err != nil {
+		return nil, err
+	}
+	releasesV2 := make([]*common.ReleaseV2, 0, len(releases))
+	for _, release := range releases {
+		sections := release.Sections
+		if sections == nil {
+			sections = []*common.ReleaseSection{}
+		}
+		releasesV2 = append(releasesV2, &common.ReleaseV2{
+			TagName:     release.TagName,
+			ReleaseName: release.ReleaseName,
+			CreatedAt:   release.CreatedAt,
+			PublishedAt: release.PublishedAt,
+			TagLink:     release.TagLink,
+			Repo:        release.Repo,
+			Channel:     release.Channel,
+			Prerequisite: &common.ReleasePrerequisiteV2{
+				Required: release.Prerequisite,
+				Message:  release.PrerequisiteMessage,
+			},
+			Notes: &common.ReleaseNotesV2{
+				Body:     release.Body,
+				Sections: sections,
+			},
+		})
+	}
+	return releasesV2, nil
 }
 
-func (impl *ReleaseNoteServiceImpl) GetReleases() ([]*common.Release, error) {
-	var releaseList []*common.Release
-	// Removing Postgres dependancy if cloud is configured
-	if impl.blobConfig.CloudConfigured {
-		// Getting from blob with latest tagName
-		latestTagFromBlob, err := impl.getLatestTagFromBlobStorage()
+// GetReleasesInRange returns releases in half open range (from, to], i.e. everything newer than the
+// installed version from up to and including to. Empty to means latest release.
+// Tags which are not valid semver are ignored.
+func (impl *ReleaseNoteServiceImpl) GetReleasesInRange(from string, to string) (*common.ReleaseRange, error) {
+	if len(from) == 0 {
+		return nil, &InvalidVersionError{Param: "from", Message: "version is required"}
+	}
+	fromVersion, err := semver.Parse(from)
+	if err != nil {
+		return nil, &InvalidVersionError{Param: "from", Message: err.Error()}
+	}
+	var toVersion *semver.Version
+	if len(to) > 0 {
+		toVersion, err = semver.Parse(to)
 		if err != nil {
-			return releaseList, err
+			return nil, &InvalidVersionError{Param: "to", Message: err.Error()}
 		}
-		var tagNameFromCache string
-		if len(releaseCache) > 0 {
-			tagNameFromCache = releaseCache[CACHE_KEY][0].TagName
+		if toVersion.Compare(fromVersion) < 0 {
+			return nil, &InvalidVersionError{Param: "to", Message: fmt.Sprintf("version %s is lower than from version %s", to, from)}
 		}
-		// if latest release tag is same with cache, return from cache
-		if tagNameFromCache == latestTagFromBlob {
-			return releaseCache[CACHE_KEY], nil
-		} else if tagNameFromCache != latestTagFromBlob {
-			// If tagName differ get it from github and update cache and upload to blob
-			releaseList, err = impl.GetReleasesFromGithubWithRetry()
-			if err != nil {
-				return releaseList, err
-			}
-			// Updating Cache and Updating tagName on blob
-			if len(releaseList) > 0 {
-				releaseCache[CACHE_KEY] = releaseList
-				releaseInfo := releaseList[0]
-				_, err = impl.updateTagToBlobStorage(releaseInfo)
-				if err != nil {
-					return releaseList, err
-				}
-			}
-			return releaseList, nil
-		}
-		return releaseList, err
 	}
-	releaseNoteObj, err := impl.getActiveReleaseNote()
-	if err != nil && err != pg.ErrNoRows {
-		impl.logger.Errorw("error in getting release notes from DB", "err", err)
-		return releaseList, err
+	// versions are compared within primary repo only, component repos follow their own versioning
+	releaseFeedCache, err := impl.getRepoReleaseFeedCache("")
+	if err != nil {
+		return nil, err
+	}
+	releases := releaseFeedCache.getFeed(impl.getRangeChannel(releaseFeedCache.releases, toVersion), impl.releaseChannelClassifier)
+	releaseRange := &common.ReleaseRange{
+		From:          from,
+		To:            to,
+		Releases:      []*common.Release{},
+		Prerequisites: []*common.ReleasePrerequisite{},
 	}
-	if releaseNoteObj != nil {
-		releaseNotes := releaseNoteObj.ReleaseNote
-		if len(releaseNotes) > 0 {
-			releaseList = append(releaseList, releaseNotes...)
+	var latestVersion *semver.Version
+	for _, release := range releases {
+		version, err := semver.Parse(release.TagName)
+		if err != nil {
+			impl.logger.Debugw("ignoring release with invalid semver tag", "tagName", release.TagName)
+			continue
+		}
+		if version.Compare(fromVersion) <= 0 || (toVersion != nil && version.Compare(toVersion) > 0) {
+			continue
 		}
+		if latestVersion == nil || version.Compare(latestVersion) > 0 {
+			latestVersion = version
+			releaseRange.To = release.TagName
+		}
+		releaseRange.Releases = append(releaseRange.Releases, release)
 	}
-	if releaseList == nil {
-		releaseList, err = impl.GetReleasesFromGithubWithRetry()
-		if err != nil {
-			return releaseList, err
+	sort.SliceStable(releaseRange.Releases, func(i, j int) bool {
+		result, _ := semver.Compare(releaseRange.Releases[i].TagName, releaseRange.Releases[j].TagName)
+		return result > 0
+	})
+	// prerequisites are applied from the oldest release to the newest one
+	for i := len(releaseRange.Releases) - 1; i >= 0; i-- {
+		release := releaseRange.Releases[i]
+		if !release.Prerequisite || len(strings.TrimSpace(release.PrerequisiteMessage)) == 0 {
+			continue
 		}
-		impl.mutex.Lock()
-		defer impl.mutex.Unlock()
-		impl.updateReleaseNotesInDb(releaseList, false)
+		releaseRange.Prerequisites = append(releaseRange.Prerequisites, &common.ReleasePrerequisite{
+			TagName:             release.TagName,
+			PrerequisiteMessage: release.PrerequisiteMessage,
+			TagLink:             release.TagLink,
+		})
 	}
-	return releaseList, nil
+	return releaseRange, nil
 }
 
-func (impl *ReleaseNoteServiceImpl) GetReleasesFromGithubWithRetry() ([]*common.Release, error) {
-	var releaseList []*common.Release
-	operationComplete := false
-	retryCount := 0
-	for !operationComplete && retryCount < 3 {
-		retryCount = retryCount + 1
-		releasesDto, releaseStatus := impl.GetReleasesFromGithub()
-		if !releaseStatus {
-			continue
+// getRangeChannel returns channel of release having to version so that a range up to an rc includes rc releases,
+// default channel is used when to is latest or not found
+func (impl *ReleaseNoteServiceImpl) getRangeChannel(releases []*common.Release, toVersion *semver.Version) string {
+	channel, _ := impl.releaseChannelClassifier.Resolve("")
+	if toVersion == nil {
+		return channel
+	}
+	for _, release := range releases {
+		version, err := semver.Parse(release.TagName)
+		if err == nil && version.Compare(toVersion) == 0 {
+			return impl.releaseChannelClassifier.Classify(release)
 		}
-		operationComplete = releaseStatus
-		releaseList = releasesDto
 	}
-	if !operationComplete {
-		return releaseList, fmt.Errorf("failed operation on fetching releases from github, attempted 3 times")
-	}
-	return releaseList, nil
+	return channel
 }
 
-func (impl *ReleaseNoteServiceImpl) getLatestTagFromBlobStorage() (string, error) {
-	blobStorageService := blob_storage.NewBlobStorageServiceImpl(nil)
-	request := impl.createBlobStorageRequest(impl.blobConfig.BlobStorageType, LATEST_FILENAME, BLOB_LATEST_RELEASE_FILE_NAME)
-	status, _, err := blobStorageService.Get(request)
-	if !status {
-		impl.logger.Errorw("error in downloading file from blob", "err", err, "request", request)
-		return "", err
-	} else if err != nil {
-		impl.logger.Errorw("error in getting file from blob", "err", err)
-		return "", err
-	}
-	// Reading File Downloaded from Blob Storage
-	content, err := os.ReadFile("/" + BLOB_LATEST_RELEASE_FILE_NAME)
-	if err != nil {
-		impl.logger.Errorw("error in reading file downloaded from s3")
-		return "", err
+// GetReleasesFromSourceWithRetry retries failed listings with backoff, see GitHubClient.GetRetryDelay
+func (impl *ReleaseNoteServiceImpl) GetReleasesFromSourceWithRetry(repo string) ([]*common.Release, error) {
+	attempt := 1
+	for {
+		releaseList, err := impl.releaseSource.ListReleases(repo)
+		if err == nil {
+			for _, release := range releaseList {
+				impl.enrichRelease(release)
+				release.Repo = repo
+			}
+			return releaseList, nil
+		}
+		impl.logger.Errorw("error in fetching releases from release source", "provider", impl.releaseSource.Provider(), "repo", repo, "attempt", attempt, "err", err)
+		delay, retry := impl.client.GetRetryDelay(err, attempt)
+		if !retry {
+			if rateLimitErr, ok := err.(*github.RateLimitError); ok {
+				impl.logger.Warnw("github rate limit exhausted, not retrying", "reset", rateLimitErr.Rate.Reset.Time)
+			}
+			return nil, fmt.Errorf("failed operation on fetching releases of %s from %s, attempted %d times, %w", repo, impl.releaseSource.Provider(), attempt, err)
+		}
+		impl.logger.Infow("retrying release listing", "repo", repo, "attempt", attempt, "delay", delay)
+		time.Sleep(delay)
+		attempt++
 	}
-	latestTagFromBlob := string(content)
-	latestTagFromBlob = strings.ReplaceAll(latestTagFromBlob, "\n", "")
-	return latestTagFromBlob, nil
 }
 
 func (impl *ReleaseNoteServiceImpl) getPrerequisiteContent(releaseInfo *common.Release) {
@@ -500,121 +581,20 @@ func (impl *ReleaseNoteServiceImpl) GetModuleByName(name string) (*common.Module
 	return module, nil
 }
 
-func (impl *ReleaseNoteServiceImpl) getActiveReleaseNote() (*releaseNote.ReleaseNote, error) {
-	releaseNoteObj, err := impl.releaseNoteRepository.FindActive()
-	if err != nil {
-		impl.logger.Errorw("error in getting release notes from DB", "err", err)
-		return nil, err
-	}
-	return releaseNoteObj, nil
-}
-
-func (impl *ReleaseNoteServiceImpl) updateReleaseNotesInDb(releaseList []*common.Release, webhookResult bool) error {
-	// initiate tx
-	dbConnection := impl.releaseNoteRepository.GetConnection()
-	tx, err := dbConnection.Begin()
-	if err != nil {
-		return err
-	}
-	// rollback tx on error.
-	defer tx.Rollback()
-
-	// STEP-1 - mark inactive in DB
-	releaseNoteObj, err := impl.getActiveReleaseNote()
-	if err != nil {
-		if err == pg.ErrNoRows && !webhookResult {
-			impl.logger.Warn("Ignoring error of no result found of active release note")
-		} else {
-			impl.logger.Errorw("error in getting release notes from DB", "err", err)
-			return err
-		}
-	}
-
-	// mark inactive
-	if releaseNoteObj != nil && releaseNoteObj.Id > 0 {
-		releaseNoteObj.UpdatedOn = time.Now()
-		releaseNoteObj.IsActive = false
-		err = impl.releaseNoteRepository.Update(releaseNoteObj, tx)
-		if err != nil {
-			return err
-		}
-	}
-
-	// STEP-2 insert active in DB
-	releaseNote := &releaseNote.ReleaseNote{
-		ReleaseNote: releaseList,
-		IsActive:    true,
-		CreatedOn:   time.Now(),
-	}
-	err = impl.releaseNoteRepository.Save(releaseNote, tx)
-	if err != nil {
-		return err
-	}
-
-	err = tx.Commit()
-	return err
-}
-
 func (impl *ReleaseNoteServiceImpl) GetReleasesOnInitialisation() {
-	// Getting releases from github on Initialisation(will try 3 times if failed)
-	releases, err := impl.GetReleasesFromGithubWithRetry()
-	if err != nil {
-		impl.logger.Errorw("error in getting releases from github on initialisation", "err", fmt.Errorf("failed operation on fetching releases from github, attempted 3 times"))
-		return
-	}
-	if len(releases) > 0 {
-		releaseCache[CACHE_KEY] = releases
-		releaseInfo := releases[0]
-		_, err = impl.updateTagToBlobStorage(releaseInfo)
+	for _, repo := range impl.releaseRepositoryRegistry.Repos() {
+		releaseRepository := impl.releaseRepositoryRegistry.Get(repo)
+		// replicas warm up from releases stored by others, release source is called only if they are stale
+		stale, err := releaseRepository.IsStale()
 		if err != nil {
-			impl.logger.Errorw("error in updating on blob", "err", err, "tagName", releaseInfo.TagName)
-		}
-
-	}
-}
-
-func (impl *ReleaseNoteServiceImpl) createBlobStorageRequest(cloudProvider blob_storage.BlobStorageType, sourceKey string, destinationKey string) *blob_storage.BlobStorageRequest {
-	request := &blob_storage.BlobStorageRequest{
-		StorageType:    cloudProvider,
-		SourceKey:      sourceKey,
-		DestinationKey: destinationKey,
-	}
-	switch impl.blobConfig.BlobStorageType {
-	case blob_storage.BLOB_STORAGE_S3:
-		{
-			var awsS3BaseConfig *blob_storage.AwsS3BaseConfig
-
-			awsS3BaseConfig = &blob_storage.AwsS3BaseConfig{
-				AccessKey:         impl.blobConfig.S3AccessKey,
-				Passkey:           impl.blobConfig.S3Passkey,
-				EndpointUrl:       impl.blobConfig.S3EndpointUrl,
-				IsInSecure:        impl.blobConfig.S3IsInSecure,
-				BucketName:        impl.blobConfig.S3BucketName,
-				Region:            impl.blobConfig.S3Region,
-				VersioningEnabled: impl.blobConfig.S3VersioningEnabled,
-			}
-			request.AwsS3BaseConfig = awsS3BaseConfig
-
-		}
-	case blob_storage.BLOB_STORAGE_AZURE:
-		{
-			azureBlobBaseConfig := &blob_storage.AzureBlobBaseConfig{
-				AccountKey:        impl.blobConfig.AzureAccountKey,
-				AccountName:       impl.blobConfig.AzureAccountName,
-				Enabled:           impl.blobConfig.AzureEnabled,
-				BlobContainerName: impl.blobConfig.AzureBlobContainerName,
-			}
-			request.AzureBlobBaseConfig = azureBlobBaseConfig
-
+			impl.logger.Warnw("error in checking stored releases on initialisation, fetching from release source", "repo", repo, "err", err)
+		} else if !stale {
+			continue
 		}
-	case blob_storage.BLOB_STORAGE_GCP:
-		{
-			gcpBlobBaseConfig := &blob_storage.GcpBlobBaseConfig{
-				CredentialFileJsonData: impl.blobConfig.GcpCredentialFileJsonData,
-				BucketName:             impl.blobConfig.GcpBucketName,
-			}
-			request.GcpBlobBaseConfig = gcpBlobBaseConfig
+		// Getting releases from release source on Initialisation(retried with backoff if failed)
+		err = impl.refreshReleasesFromSource(repo, releaseRepository)
+		if err != nil {
+			impl.logger.Errorw("error in getting releases from release source on initialisation", "repo", repo, "err", err)
 		}
 	}
-	return request
 }
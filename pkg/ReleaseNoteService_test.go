@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devtron-labs/central-api/common"
+	"go.uber.org/zap"
+)
+
+// fixedReleaseSource lists fixed releases and decodes every payload to the same event
+type fixedReleaseSource struct {
+	ReleaseSource
+	releases     []*common.Release
+	releaseEvent *ReleaseEvent
+}
+
+func (impl *fixedReleaseSource) Provider() string {
+	return "fixed"
+}
+
+func (impl *fixedReleaseSource) ListReleases(repo string) ([]*common.Release, error) {
+	releases := make([]*common.Release, 0, len(impl.releases))
+	for _, release := range impl.releases {
+		copied := *release
+		releases = append(releases, &copied)
+	}
+	return releases, nil
+}
+
+func (impl *fixedReleaseSource) DecodeReleaseEvent(requestBodyBytes []byte) (*ReleaseEvent, error) {
+	release := *impl.releaseEvent.Release
+	return &ReleaseEvent{Action: impl.releaseEvent.Action, Repo: impl.releaseEvent.Repo, Release: &release}, nil
+}
+
+func TestUpdateReleasesRefreshesStaleRepository(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	releaseSource := &fixedReleaseSource{
+		releases: []*common.Release{
+			newTestRelease("v1.1.0", base.Add(2*time.Hour), false),
+			newTestRelease("v1.0.0", base.Add(time.Hour), false),
+		},
+		releaseEvent: &ReleaseEvent{Action: ActionPublished, Repo: conformanceRepo, Release: newTestRelease("v1.2.0", base.Add(3*time.Hour), false)},
+	}
+	newRepository := newTestReleaseBlobRepositoryFactory(t)
+	releaseNoteService := &ReleaseNoteServiceImpl{
+		logger: zap.NewNop().Sugar(),
+		releaseRepositoryRegistry: &ReleaseRepositoryRegistryImpl{
+			repos:               []string{conformanceRepo},
+			releaseRepositories: map[string]ReleaseRepository{conformanceRepo: newRepository()},
+		},
+		releaseSource:            releaseSource,
+		releaseChannelClassifier: newTestReleaseChannelClassifier(t),
+		releaseFeedCaches:        newReleaseFeedCaches(),
+	}
+
+	// first delivery after deploy on a fresh bucket, releases are fetched before the event is applied
+	updated, err := releaseNoteService.UpdateReleases(nil)
+	if err != nil || !updated {
+		t.Fatalf("UpdateReleases = %v, %v, want true, nil", updated, err)
+	}
+	replica := newRepository()
+	stale, err := replica.IsStale()
+	if err != nil || stale {
+		t.Fatalf("IsStale of replica = %v, %v, want false, nil", stale, err)
+	}
+	assertReleaseTags(t, replica, 0, 0, "v1.2.0", "v1.1.0", "v1.0.0")
+}
+
+func TestGetReleasesInRangeInvalidVersion(t *testing.T) {
+	releaseNoteService := &ReleaseNoteServiceImpl{logger: zap.NewNop().Sugar()}
+	tests := []struct {
+		from      string
+		to        string
+		wantParam string
+	}{
+		{from: "", to: "v1.0.0", wantParam: "from"},
+		{from: "latest", to: "", wantParam: "from"},
+		{from: "v1.0.0", to: "next", wantParam: "to"},
+		{from: "v1.1.0", to: "v1.0.0", wantParam: "to"},
+	}
+	for _, tt := range tests {
+		_, err := releaseNoteService.GetReleasesInRange(tt.from, tt.to)
+		invalidVersionError, ok := err.(*InvalidVersionError)
+		if !ok {
+			t.Errorf("GetReleasesInRange(%q, %q) error = %v, want InvalidVersionError", tt.from, tt.to, err)
+			continue
+		}
+		if invalidVersionError.Param != tt.wantParam {
+			t.Errorf("GetReleasesInRange(%q, %q) invalid param = %s, want %s", tt.from, tt.to, invalidVersionError.Param, tt.wantParam)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/pkg/releaseNote"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// ReleasePostgresRepositoryImpl keeps one row per release of repo, rows are shared by all the replicas
+type ReleasePostgresRepositoryImpl struct {
+	logger            *zap.SugaredLogger
+	mutex             sync.Mutex
+	repo              string
+	releaseRepository releaseNote.ReleaseRepository
+}
+
+func NewReleasePostgresRepositoryImpl(logger *zap.SugaredLogger, releaseRepository releaseNote.ReleaseRepository, repo string) *ReleasePostgresRepositoryImpl {
+	return &ReleasePostgresRepositoryImpl{
+		logger:            logger,
+		repo:              repo,
+		releaseRepository: releaseRepository,
+	}
+}
+
+func (impl *ReleasePostgresRepositoryImpl) List(offset int, size int) ([]*common.Release, error) {
+	releaseList := make([]*common.Release, 0)
+	releases, err := impl.releaseRepository.List(impl.repo, offset, size)
+	if err != nil && err != pg.ErrNoRows {
+		impl.logger.Errorw("error in getting releases from DB", "repo", impl.repo, "offset", offset, "size", size, "err", err)
+		return releaseList, err
+	}
+	for _, release := range releases {
+		releaseList = append(releaseList, adaptReleaseFromDb(release))
+	}
+	return releaseList, nil
+}
+
+func (impl *ReleasePostgresRepositoryImpl) Save(release *common.Release) error {
+	impl.mutex.Lock()
+	defer impl.mutex.Unlock()
+	err := impl.upsertReleasesInDb([]*common.Release{release}, false)
+	if err != nil {
+		impl.logger.Errorw("error in saving release in DB", "repo", impl.repo, "tagName", release.TagName, "err", err)
+	}
+	return err
+}
+
+func (impl *ReleasePostgresRepositoryImpl) Delete(tagName string) error {
+	impl.mutex.Lock()
+	defer impl.mutex.Unlock()
+	err := impl.releaseRepository.Delete(impl.repo, tagName)
+	if err != nil {
+		impl.logger.Errorw("error in deleting release from DB", "repo", impl.repo, "tagName", tagName, "err", err)
+	}
+	return err
+}
+
+func (impl *ReleasePostgresRepositoryImpl) ReplaceAll(releases []*common.Release) error {
+	impl.mutex.Lock()
+	defer impl.mutex.Unlock()
+	err := impl.upsertReleasesInDb(releases, true)
+	if err != nil {
+		impl.logger.Errorw("error in saving releases in DB", "repo", impl.repo, "err", err)
+	}
+	return err
+}
+
+// IsStale returns true only when nothing is stored yet, rows are kept up to date by webhook events
+func (impl *ReleasePostgresRepositoryImpl) IsStale() (bool, error) {
+	exists, err := impl.releaseRepository.Exists(impl.repo)
+	if err != nil {
+		impl.logger.Errorw("error in checking releases in DB", "repo", impl.repo, "err", err)
+		return false, err
+	}
+	return !exists, nil
+}
+
+// Version is read from DB on every call as rows are changed by every replica
+func (impl *ReleasePostgresRepositoryImpl) Version() (string, error) {
+	version, err := impl.releaseRepository.GetVersion(impl.repo)
+	if err != nil {
+		impl.logger.Errorw("error in getting version of releases from DB", "repo", impl.repo, "err", err)
+	}
+	return version, err
+}
+
+// upsertReleasesInDb upserts releases in a single tx, rows of other tags are deleted in same tx if deleteOthers is set
+func (impl *ReleasePostgresRepositoryImpl) upsertReleasesInDb(releaseList []*common.Release, deleteOthers bool) error {
+	// initiate tx
+	dbConnection := impl.releaseRepository.GetConnection()
+	tx, err := dbConnection.Begin()
+	if err != nil {
+		return err
+	}
+	// rollback tx on error.
+	defer tx.Rollback()
+
+	tagNames := make([]string, 0, len(releaseList))
+	for _, release := range releaseList {
+		if release == nil || len(release.TagName) == 0 {
+			continue
+		}
+		err = impl.releaseRepository.Upsert(adaptReleaseToDb(impl.repo, release), tx)
+		if err != nil {
+			impl.logger.Errorw("error in upserting release", "tagName", release.TagName, "err", err)
+			return err
+		}
+		tagNames = append(tagNames, release.TagName)
+	}
+	if deleteOthers {
+		err = impl.releaseRepository.DeleteNotIn(impl.repo, tagNames, tx)
+		if err != nil {
+			impl.logger.Errorw("error in deleting releases not present in list", "err", err)
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+func adaptReleaseToDb(repo string, release *common.Release) *releaseNote.Release {
+	return &releaseNote.Release{
+		Repo:                repo,
+		TagName:             release.TagName,
+		ReleaseName:         release.ReleaseName,
+		Body:                release.Body,
+		Prerequisite:        release.Prerequisite,
+		PrerequisiteMessage: release.PrerequisiteMessage,
+		TagLink:             release.TagLink,
+		Sections:            release.Sections,
+		Prerelease:          release.Prerelease,
+		Draft:               release.Draft,
+		CreatedAt:           release.CreatedAt,
+		PublishedAt:         release.PublishedAt,
+		CreatedOn:           time.Now(),
+		UpdatedOn:           time.Now(),
+	}
+}
+
+func adaptReleaseFromDb(release *releaseNote.Release) *common.Release {
+	sections := release.Sections
+	if sections == nil && len(release.Body) > 0 {
+		// rows stored before sections were introduced
+		sections = ParseReleaseBody(release.Body)
+	}
+	return &common.Release{
+		TagName:             release.TagName,
+		ReleaseName:         release.ReleaseName,
+		Body:                release.Body,
+		Prerequisite:        release.Prerequisite,
+		PrerequisiteMessage: release.PrerequisiteMessage,
+		TagLink:             release.TagLink,
+		Sections:            sections,
+		Prerelease:          release.Prerelease,
+		Draft:               release.Draft,
+		CreatedAt:           release.CreatedAt,
+		PublishedAt:         release.PublishedAt,
+		Repo:                release.Repo,
+	}
+}
@@ -0,0 +1,262 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/internal/metrics"
+	"github.com/devtron-labs/central-api/pkg/lock"
+	"go.uber.org/zap"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const releaseReconcileLockName = "release-reconcile"
+
+const (
+	ReconcileResultSuccess = "success"
+	ReconcileResultFailed  = "failed"
+	ReconcileResultSkipped = "skipped"
+)
+
+var releaseReconcileRuns = metrics.NewCounterVec("central_api_release_reconcile_runs_total",
+	"release reconcile runs by result, skipped when another replica holds the lock", "result")
+var releaseReconcileDrift = metrics.NewCounterVec("central_api_release_reconcile_drift_total",
+	"releases found different from release source during reconcile by type", "type")
+var releaseReconcileLastSuccess = metrics.NewGaugeVec("central_api_release_reconcile_last_success_timestamp_seconds",
+	"unix time of last successful release reconcile run")
+
+// ReleaseDrift lists tags of repo which differed from release source in a reconcile run
+type ReleaseDrift struct {
+	Repo    string
+	Added   []string
+	Updated []string
+	Removed []string
+}
+
+func (drift *ReleaseDrift) Count() int {
+	return len(drift.Added) + len(drift.Updated) + len(drift.Removed)
+}
+
+type ReleaseReconcileService interface {
+	// Reconcile resyncs stored releases of every repo with release source and applies only the differences, a failed
+	// repo does not stop the others. It returns nil drifts without error when another replica is running it.
+	Reconcile() ([]*ReleaseDrift, error)
+	// Stop stops the scheduler, a run in progress is completed
+	Stop()
+}
+
+type ReleaseReconcileServiceImpl struct {
+	logger                    *zap.SugaredLogger
+	config                    *util.ReconcileConfig
+	releaseSource             ReleaseSource
+	releaseNoteService        ReleaseNoteService
+	releaseRepositoryRegistry ReleaseRepositoryRegistry
+	distributedLock           lock.DistributedLock
+	random                    *rand.Rand
+	stop                      chan struct{}
+	stopOnce                  sync.Once
+	waitGroup                 sync.WaitGroup
+}
+
+func NewReleaseReconcileServiceImpl(logger *zap.SugaredLogger, config *util.ReconcileConfig, releaseSource ReleaseSource,
+	releaseNoteService ReleaseNoteService, releaseRepositoryRegistry ReleaseRepositoryRegistry, distributedLock lock.DistributedLock) *ReleaseReconcileServiceImpl {
+	impl := &ReleaseReconcileServiceImpl{
+		logger:                    logger,
+		config:                    config,
+		releaseSource:             releaseSource,
+		releaseNoteService:        releaseNoteService,
+		releaseRepositoryRegistry: releaseRepositoryRegistry,
+		distributedLock:           distributedLock,
+		random:                    rand.New(rand.NewSource(time.Now().UnixNano())),
+		stop:                      make(chan struct{}),
+	}
+	if config.Enabled && config.Interval > 0 {
+		impl.waitGroup.Add(1)
+		go impl.schedule()
+	} else {
+		logger.Infow("release reconcile is disabled")
+	}
+	return impl
+}
+
+func (impl *ReleaseReconcileServiceImpl) Stop() {
+	impl.stopOnce.Do(func() {
+		close(impl.stop)
+	})
+	impl.waitGroup.Wait()
+}
+
+// schedule runs reconcile after every interval plus jitter, first run happens one interval after startup
+// since releases are already loaded on initialisation
+func (impl *ReleaseReconcileServiceImpl) schedule() {
+	defer impl.waitGroup.Done()
+	for {
+		timer := time.NewTimer(impl.nextDelay())
+		select {
+		case <-impl.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		_, _ = impl.Reconcile()
+	}
+}
+
+func (impl *ReleaseReconcileServiceImpl) nextDelay() time.Duration {
+	delay := impl.config.Interval
+	if impl.config.Jitter > 0 {
+		delay += time.Duration(impl.random.Int63n(int64(impl.config.Jitter)))
+	}
+	return delay
+}
+
+func (impl *ReleaseReconcileServiceImpl) Reconcile() ([]*ReleaseDrift, error) {
+	release, acquired, err := impl.distributedLock.TryAcquire(releaseReconcileLockName, impl.config.LockTTL)
+	if err != nil {
+		impl.logger.Errorw("error in acquiring release reconcile lock", "err", err)
+		releaseReconcileRuns.Inc(ReconcileResultFailed)
+		return nil, err
+	}
+	if !acquired {
+		impl.logger.Infow("release reconcile is running on another replica, skipping")
+		releaseReconcileRuns.Inc(ReconcileResultSkipped)
+		return nil, nil
+	}
+	defer release()
+	var drifts []*ReleaseDrift
+	var reconcileErr error
+	for _, repo := range impl.releaseRepositoryRegistry.Repos() {
+		startTime := time.Now()
+		drift, err := impl.reconcile(repo)
+		if drift != nil {
+			drifts = append(drifts, drift)
+			releaseReconcileDrift.Add(float64(len(drift.Added)), "added")
+			releaseReconcileDrift.Add(float64(len(drift.Updated)), "updated")
+			releaseReconcileDrift.Add(float64(len(drift.Removed)), "removed")
+		}
+		if err != nil {
+			impl.logger.Errorw("error in reconciling releases with release source", "repo", repo, "err", err)
+			if reconcileErr == nil {
+				reconcileErr = err
+			}
+			continue
+		}
+		if drift.Count() > 0 {
+			impl.logger.Warnw("release drift found and fixed during reconcile", "repo", repo, "added", drift.Added, "updated", drift.Updated,
+				"removed", drift.Removed, "duration", time.Since(startTime))
+		} else {
+			impl.logger.Infow("releases are in sync with release source", "repo", repo, "duration", time.Since(startTime))
+		}
+	}
+	if reconcileErr != nil {
+		releaseReconcileRuns.Inc(ReconcileResultFailed)
+		return drifts, reconcileErr
+	}
+	releaseReconcileRuns.Inc(ReconcileResultSuccess)
+	releaseReconcileLastSuccess.Set(float64(time.Now().Unix()))
+	return drifts, nil
+}
+
+func (impl *ReleaseReconcileServiceImpl) reconcile(repo string) (*ReleaseDrift, error) {
+	releaseRepository := impl.releaseRepositoryRegistry.Get(repo)
+	sourceReleases, err := impl.releaseNoteService.GetReleasesFromSourceWithRetry(repo)
+	if err != nil {
+		return nil, err
+	}
+	// loads releases published by other replicas before comparing, result does not matter here
+	_, err = releaseRepository.IsStale()
+	if err != nil {
+		return nil, err
+	}
+	storedReleases, err := releaseRepository.List(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	drift := &ReleaseDrift{Repo: repo}
+	storedByTag := make(map[string]*common.Release, len(storedReleases))
+	for _, release := range storedReleases {
+		storedByTag[release.TagName] = release
+	}
+	// drift is applied with a single ReplaceAll, snapshot based repositories publish every release on each write
+	releases := make([]*common.Release, 0, len(sourceReleases)+len(storedReleases))
+	sourceByTag := make(map[string]*common.Release, len(sourceReleases))
+	for _, release := range sourceReleases {
+		sourceByTag[release.TagName] = release
+		releases = append(releases, release)
+		stored, ok := storedByTag[release.TagName]
+		switch {
+		case release.Draft:
+			if ok {
+				// unpublished while webhooks were missed, kept as draft like the unpublished webhook does
+				drift.Removed = append(drift.Removed, release.TagName)
+			}
+		case !ok:
+			drift.Added = append(drift.Added, release.TagName)
+		case !isSameRelease(stored, release):
+			drift.Updated = append(drift.Updated, release.TagName)
+		}
+	}
+	oldestFetched := impl.getOldestFetchedPublishTime(sourceReleases)
+	for _, stored := range storedReleases {
+		if _, ok := sourceByTag[stored.TagName]; ok {
+			continue
+		}
+		if stored.PublishedAt.Before(oldestFetched) {
+			// older than the releases fetched under max count, release source was not asked about it
+			releases = append(releases, stored)
+			continue
+		}
+		drift.Removed = append(drift.Removed, stored.TagName)
+	}
+	if drift.Count() == 0 {
+		return drift, nil
+	}
+	return drift, releaseRepository.ReplaceAll(releases)
+}
+
+// getOldestFetchedPublishTime returns zero time unless release source listing was cut at its max count
+func (impl *ReleaseReconcileServiceImpl) getOldestFetchedPublishTime(sourceReleases []*common.Release) time.Time {
+	var oldest time.Time
+	maxCount := impl.releaseSource.ReleasesMaxCount()
+	if maxCount <= 0 || len(sourceReleases) < maxCount {
+		return oldest
+	}
+	for _, release := range sourceReleases {
+		if release.Draft {
+			continue
+		}
+		if oldest.IsZero() || release.PublishedAt.Before(oldest) {
+			oldest = release.PublishedAt
+		}
+	}
+	return oldest
+}
+
+func isSameRelease(stored *common.Release, fetched *common.Release) bool {
+	return stored.ReleaseName == fetched.ReleaseName &&
+		stored.Body == fetched.Body &&
+		stored.TagLink == fetched.TagLink &&
+		stored.Prerequisite == fetched.Prerequisite &&
+		stored.PrerequisiteMessage == fetched.PrerequisiteMessage &&
+		stored.Prerelease == fetched.Prerelease &&
+		stored.Draft == fetched.Draft &&
+		stored.CreatedAt.Equal(fetched.CreatedAt) &&
+		stored.PublishedAt.Equal(fetched.PublishedAt)
+}
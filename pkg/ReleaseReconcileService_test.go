@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/pkg/lock"
+	"go.uber.org/zap"
+)
+
+// countingReleaseRepository counts writes made to the wrapped repository
+type countingReleaseRepository struct {
+	ReleaseRepository
+	saves       int
+	deletes     int
+	replaceAlls int
+}
+
+func (impl *countingReleaseRepository) Save(release *common.Release) error {
+	impl.saves++
+	return impl.ReleaseRepository.Save(release)
+}
+
+func (impl *countingReleaseRepository) Delete(tagName string) error {
+	impl.deletes++
+	return impl.ReleaseRepository.Delete(tagName)
+}
+
+func (impl *countingReleaseRepository) ReplaceAll(releases []*common.Release) error {
+	impl.replaceAlls++
+	return impl.ReleaseRepository.ReplaceAll(releases)
+}
+
+// sourceReleaseNoteService returns fixed releases as the ones fetched from release source
+type sourceReleaseNoteService struct {
+	ReleaseNoteService
+	releases []*common.Release
+}
+
+func (impl *sourceReleaseNoteService) GetReleasesFromSourceWithRetry(repo string) ([]*common.Release, error) {
+	releases := make([]*common.Release, 0, len(impl.releases))
+	for _, release := range impl.releases {
+		copied := *release
+		releases = append(releases, &copied)
+	}
+	return releases, nil
+}
+
+type maxCountReleaseSource struct {
+	ReleaseSource
+	maxCount int
+}
+
+func (impl *maxCountReleaseSource) ReleasesMaxCount() int {
+	return impl.maxCount
+}
+
+func TestReconcileAppliesDriftWithSingleWrite(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	releaseRepository := &countingReleaseRepository{ReleaseRepository: NewReleaseInMemoryRepositoryImpl(logger, NewReleaseStoreImpl(logger))}
+	err := releaseRepository.ReleaseRepository.ReplaceAll([]*common.Release{
+		newTestRelease("v1.3.1", base.Add(45*time.Minute), false),
+		newTestRelease("v1.3.0", base.Add(40*time.Minute), false),
+		newTestRelease("v1.2.0", base.Add(30*time.Minute), false),
+		newTestRelease("v1.1.0", base.Add(20*time.Minute), false),
+		newTestRelease("v1.0.0", base.Add(10*time.Minute), false),
+	})
+	if err != nil {
+		t.Fatalf("ReplaceAll: %v", err)
+	}
+	editedRelease := newTestRelease("v1.3.0", base.Add(40*time.Minute), false)
+	editedRelease.Body = "## Bugs\n- edited"
+	// listing is cut at max count, v1.1.0 and v1.0.0 are older than every fetched release
+	releaseNoteService := &sourceReleaseNoteService{releases: []*common.Release{
+		newTestRelease("v1.4.0", base.Add(50*time.Minute), false),
+		editedRelease,
+		newTestRelease("v1.2.0", base.Add(30*time.Minute), true),
+	}}
+	releaseRepositoryRegistry := &ReleaseRepositoryRegistryImpl{
+		repos:               []string{conformanceRepo},
+		releaseRepositories: map[string]ReleaseRepository{conformanceRepo: releaseRepository},
+	}
+	releaseReconcileService := NewReleaseReconcileServiceImpl(logger, &util.ReconcileConfig{}, &maxCountReleaseSource{maxCount: 3},
+		releaseNoteService, releaseRepositoryRegistry, lock.NewLocalLockImpl())
+	defer releaseReconcileService.Stop()
+
+	drifts, err := releaseReconcileService.Reconcile()
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	wantDrift := &ReleaseDrift{Repo: conformanceRepo, Added: []string{"v1.4.0"}, Updated: []string{"v1.3.0"}, Removed: []string{"v1.2.0", "v1.3.1"}}
+	if len(drifts) != 1 || !reflect.DeepEqual(drifts[0], wantDrift) {
+		t.Fatalf("Reconcile() drifts = %+v, want %+v", drifts, wantDrift)
+	}
+	if releaseRepository.replaceAlls != 1 || releaseRepository.saves != 0 || releaseRepository.deletes != 0 {
+		t.Errorf("Reconcile() wrote %d ReplaceAll, %d Save and %d Delete, want a single ReplaceAll",
+			releaseRepository.replaceAlls, releaseRepository.saves, releaseRepository.deletes)
+	}
+	releases, err := releaseRepository.List(0, 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var tagNames []string
+	for _, release := range releases {
+		tagNames = append(tagNames, release.TagName)
+	}
+	if want := []string{"v1.4.0", "v1.3.0", "v1.1.0", "v1.0.0"}; !reflect.DeepEqual(tagNames, want) {
+		t.Errorf("stored releases after Reconcile() = %v, want %v", tagNames, want)
+	}
+	if releases[1].Body != editedRelease.Body {
+		t.Errorf("body of updated release = %q, want %q", releases[1].Body, editedRelease.Body)
+	}
+
+	drifts, err = releaseReconcileService.Reconcile()
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if len(drifts) != 1 || drifts[0].Count() != 0 || releaseRepository.replaceAlls != 1 {
+		t.Errorf("second Reconcile() found drift %+v with %d ReplaceAll, want no drift and no write", drifts, releaseRepository.replaceAlls)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"fmt"
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/pkg/blobStorage"
+	"github.com/devtron-labs/central-api/pkg/releaseNote"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ReleaseRepository keeps releases of a repo served by ReleaseNoteService, implementation is chosen by storage backend
+type ReleaseRepository interface {
+	// List returns non draft releases latest first, size <= 0 returns all the releases after offset
+	List(offset int, size int) ([]*common.Release, error)
+	// Save replaces release having same tag name or adds it, other releases are untouched
+	Save(release *common.Release) error
+	// Delete removes release having tag name, no-op if it is not present
+	Delete(tagName string) error
+	// ReplaceAll makes releases the complete stored list, releases not present in it are removed
+	ReplaceAll(releases []*common.Release) error
+	// IsStale returns true when stored releases have to be fetched again from release source
+	IsStale() (bool, error)
+	// Version changes whenever stored releases change, including changes made by other replicas once IsStale
+	// has picked them up. Lists can be cached until it changes.
+	Version() (string, error)
+}
+
+// ReleaseRepositoryRegistry holds a release repository per repo of release source, releases of every repo are
+// stored and checked for staleness separately
+type ReleaseRepositoryRegistry interface {
+	// Repos returns owner/repo full names, primary repo first
+	Repos() []string
+	// Resolve returns configured full name matching repo case insensitively, repo can also be a name without owner
+	// if only one configured repo has it. Empty repo resolves to primary repo.
+	Resolve(repo string) (string, bool)
+	// Get returns release repository of a resolved repo, nil if repo is not configured
+	Get(repo string) ReleaseRepository
+}
+
+type ReleaseRepositoryRegistryImpl struct {
+	repos               []string
+	releaseRepositories map[string]ReleaseRepository
+}
+
+// NewReleaseRepositoryRegistryImpl creates release repository of configured storage backend for every repo. Blob and
+// filesystem backends share the snapshot based implementation, blob adapter of filesystem backend writes to local directory.
+// dbConnection is nil unless storage backend is postgres.
+func NewReleaseRepositoryRegistryImpl(logger *zap.SugaredLogger, storageConfig *util.StorageConfig, blobAdapter blobStorage.BlobAdapter,
+	dbConnection *pg.DB, releaseSource ReleaseSource, releaseChannelClassifier ReleaseChannelClassifier) (*ReleaseRepositoryRegistryImpl, error) {
+	repos := releaseSource.Repos()
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("no repo configured for %s release source", releaseSource.Provider())
+	}
+	var releaseRepository releaseNote.ReleaseRepository
+	if storageConfig.Backend == util.STORAGE_BACKEND_POSTGRES {
+		releaseRepository = releaseNote.NewReleaseRepositoryImpl(dbConnection)
+		// rows stored before multi repo support belong to primary repo
+		assigned, err := releaseRepository.AssignRepo(repos[0])
+		if err != nil {
+			logger.Errorw("error in assigning repo to stored releases", "repo", repos[0], "err", err)
+			return nil, err
+		}
+		if assigned > 0 {
+			logger.Infow("assigned repo to releases stored before multi repo support", "repo", repos[0], "count", assigned)
+		}
+	}
+	impl := &ReleaseRepositoryRegistryImpl{
+		repos:               repos,
+		releaseRepositories: make(map[string]ReleaseRepository, len(repos)),
+	}
+	for i, repo := range repos {
+		switch storageConfig.Backend {
+		case util.STORAGE_BACKEND_POSTGRES:
+			impl.releaseRepositories[repo] = NewReleasePostgresRepositoryImpl(logger, releaseRepository, repo)
+		case util.STORAGE_BACKEND_BLOB, util.STORAGE_BACKEND_FILESYSTEM:
+			impl.releaseRepositories[repo] = NewReleaseBlobRepositoryImpl(logger, blobAdapter, NewReleaseStoreImpl(logger), repo, i == 0, releaseChannelClassifier)
+		case util.STORAGE_BACKEND_MEMORY:
+			impl.releaseRepositories[repo] = NewReleaseInMemoryRepositoryImpl(logger, NewReleaseStoreImpl(logger))
+		default:
+			return nil, fmt.Errorf("unsupported storage backend %s", storageConfig.Backend)
+		}
+	}
+	logger.Infow("release repositories created", "backend", storageConfig.Backend, "repos", repos)
+	return impl, nil
+}
+
+func (impl *ReleaseRepositoryRegistryImpl) Repos() []string {
+	return impl.repos
+}
+
+func (impl *ReleaseRepositoryRegistryImpl) Resolve(repo string) (string, bool) {
+	repo = strings.Trim(strings.TrimSpace(repo), "/")
+	if len(repo) == 0 {
+		return impl.repos[0], true
+	}
+	var nameMatch string
+	nameMatches := 0
+	for _, configured := range impl.repos {
+		if strings.EqualFold(configured, repo) {
+			return configured, true
+		}
+		if _, name := util.SplitRepoFullName(configured); strings.EqualFold(name, repo) {
+			nameMatch = configured
+			nameMatches++
+		}
+	}
+	return nameMatch, nameMatches == 1
+}
+
+func (impl *ReleaseRepositoryRegistryImpl) Get(repo string) ReleaseRepository {
+	return impl.releaseRepositories[repo]
+}
+
+// ReleaseInMemoryRepositoryImpl keeps releases in release store only, releases are fetched from release source again
+// after every restart
+type ReleaseInMemoryRepositoryImpl struct {
+	logger       *zap.SugaredLogger
+	releaseStore ReleaseStore
+	// replaced is set once releases are replaced with a full list, until then store only has releases of webhooks
+	replaced int32
+}
+
+func NewReleaseInMemoryRepositoryImpl(logger *zap.SugaredLogger, releaseStore ReleaseStore) *ReleaseInMemoryRepositoryImpl {
+	return &ReleaseInMemoryRepositoryImpl{
+		logger:       logger,
+		releaseStore: releaseStore,
+	}
+}
+
+func (impl *ReleaseInMemoryRepositoryImpl) List(offset int, size int) ([]*common.Release, error) {
+	return paginateReleases(filterPublishedReleases(impl.releaseStore.Get()), offset, size), nil
+}
+
+func (impl *ReleaseInMemoryRepositoryImpl) Save(release *common.Release) error {
+	impl.releaseStore.Upsert(release)
+	return nil
+}
+
+func (impl *ReleaseInMemoryRepositoryImpl) Delete(tagName string) error {
+	impl.releaseStore.Remove(tagName)
+	return nil
+}
+
+func (impl *ReleaseInMemoryRepositoryImpl) ReplaceAll(releases []*common.Release) error {
+	impl.releaseStore.Replace(releases)
+	atomic.StoreInt32(&impl.replaced, 1)
+	return nil
+}
+
+// IsStale is true until a full list is stored, releases saved by webhooks before the initial fetch are not enough
+func (impl *ReleaseInMemoryRepositoryImpl) IsStale() (bool, error) {
+	return atomic.LoadInt32(&impl.replaced) == 0, nil
+}
+
+func (impl *ReleaseInMemoryRepositoryImpl) Version() (string, error) {
+	return strconv.FormatUint(impl.releaseStore.Generation(), 10), nil
+}
+
+// getLatestPublishedTag returns tag of first non draft release, release lists are ordered latest first
+func getLatestPublishedTag(releaseList []*common.Release) string {
+	for _, release := range releaseList {
+		if !release.Draft {
+			return release.TagName
+		}
+	}
+	return ""
+}
+
+func filterPublishedReleases(releaseList []*common.Release) []*common.Release {
+	publishedReleases := make([]*common.Release, 0, len(releaseList))
+	for _, release := range releaseList {
+		if !release.Draft {
+			publishedReleases = append(publishedReleases, release)
+		}
+	}
+	return publishedReleases
+}
+
+func paginateReleases(releaseList []*common.Release, offset int, size int) []*common.Release {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(releaseList) {
+		return []*common.Release{}
+	}
+	if size > 0 && offset+size <= len(releaseList) {
+		return releaseList[offset : offset+size]
+	}
+	return releaseList[offset:]
+}
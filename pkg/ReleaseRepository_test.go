@@ -0,0 +1,312 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/pkg/blobStorage"
+	"github.com/devtron-labs/central-api/pkg/releaseNote"
+	"github.com/devtron-labs/central-api/pkg/sql"
+	"go.uber.org/zap"
+)
+
+const conformanceRepo = "devtron-labs/devtron"
+
+// releaseRepositoryFactory returns a new repository instance on every call, instances of shared backends
+// read and write the same storage like replicas do. Repositories of backends which need a full list stay stale
+// after saving single releases, postgres trusts rows already stored.
+type releaseRepositoryFactory struct {
+	name         string
+	shared       bool
+	needFullList bool
+	create       func(t *testing.T) func() ReleaseRepository
+}
+
+func TestReleaseRepositoryConformance(t *testing.T) {
+	factories := []releaseRepositoryFactory{
+		{name: "memory", needFullList: true, create: newInMemoryReleaseRepositoryFactory},
+		{name: "filesystem", shared: true, needFullList: true, create: newFileSystemReleaseRepositoryFactory},
+		{name: "postgres", shared: true, create: newPostgresReleaseRepositoryFactory},
+	}
+	for _, factory := range factories {
+		factory := factory
+		t.Run(factory.name, func(t *testing.T) {
+			newRepository := factory.create(t)
+			testReleaseRepository(t, newRepository, factory.shared, factory.needFullList)
+		})
+	}
+}
+
+func newInMemoryReleaseRepositoryFactory(t *testing.T) func() ReleaseRepository {
+	logger := zap.NewNop().Sugar()
+	return func() ReleaseRepository {
+		return NewReleaseInMemoryRepositoryImpl(logger, NewReleaseStoreImpl(logger))
+	}
+}
+
+func newFileSystemReleaseRepositoryFactory(t *testing.T) func() ReleaseRepository {
+	logger := zap.NewNop().Sugar()
+	blobAdapter, err := blobStorage.NewFileSystemBlobAdapterImpl(logger, t.TempDir())
+	if err != nil {
+		t.Fatalf("error in creating filesystem blob adapter: %v", err)
+	}
+	releaseChannelClassifier := newTestReleaseChannelClassifier(t)
+	return func() ReleaseRepository {
+		return NewReleaseBlobRepositoryImpl(logger, blobAdapter, NewReleaseStoreImpl(logger), conformanceRepo, true, releaseChannelClassifier)
+	}
+}
+
+// newPostgresReleaseRepositoryFactory needs a database migrated with scripts/sql, connection is read from PG_* env
+// variables and the test is skipped when PG_ADDR is not set. Rows are written under a repo unique to the run.
+func newPostgresReleaseRepositoryFactory(t *testing.T) func() ReleaseRepository {
+	if len(os.Getenv("PG_ADDR")) == 0 {
+		t.Skip("PG_ADDR is not set, skipping postgres release repository")
+	}
+	logger := zap.NewNop().Sugar()
+	dbConnection, err := sql.NewDbConnection(logger)
+	if err != nil {
+		t.Fatalf("error in connecting postgres: %v", err)
+	}
+	t.Cleanup(func() { dbConnection.Close() })
+	releaseRepository := releaseNote.NewReleaseRepositoryImpl(dbConnection)
+	repo := fmt.Sprintf("conformance/test-%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		_, err := releaseRepository.GetConnection().Model((*releaseNote.Release)(nil)).Where("repo = ?", repo).Delete()
+		if err != nil {
+			t.Errorf("error in cleaning up releases of %s: %v", repo, err)
+		}
+	})
+	return func() ReleaseRepository {
+		return NewReleasePostgresRepositoryImpl(logger, releaseRepository, repo)
+	}
+}
+
+func newTestReleaseChannelClassifier(t *testing.T) ReleaseChannelClassifier {
+	logger := zap.NewNop().Sugar()
+	config, err := util.NewReleaseChannelConfig(logger)
+	if err != nil {
+		t.Fatalf("error in parsing release channel config: %v", err)
+	}
+	releaseChannelClassifier, err := NewReleaseChannelClassifierImpl(logger, config)
+	if err != nil {
+		t.Fatalf("error in creating release channel classifier: %v", err)
+	}
+	return releaseChannelClassifier
+}
+
+func newTestRelease(tagName string, publishedAt time.Time, draft bool) *common.Release {
+	return &common.Release{
+		TagName:     tagName,
+		ReleaseName: tagName,
+		CreatedAt:   publishedAt,
+		PublishedAt: publishedAt,
+		Body:        "## Bugs\n- fixed " + tagName,
+		Draft:       draft,
+		Repo:        conformanceRepo,
+	}
+}
+
+func testReleaseRepository(t *testing.T, newRepository func() ReleaseRepository, shared bool, needFullList bool) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repository := newRepository()
+
+	stale, err := repository.IsStale()
+	if err == nil && !stale {
+		t.Fatalf("empty repository is not stale")
+	}
+
+	if needFullList {
+		// webhook delivered before the initial fetch completed or after it failed
+		err = repository.Save(newTestRelease("v1.2.0", base.Add(3*time.Hour), false))
+		if err != nil && err != ErrReleasesNotLoaded {
+			t.Fatalf("Save before ReplaceAll: %v", err)
+		}
+		stale, err = repository.IsStale()
+		if err != nil || !stale {
+			t.Fatalf("IsStale after Save before ReplaceAll = %v, %v, want true, nil", stale, err)
+		}
+	}
+
+	err = repository.ReplaceAll([]*common.Release{
+		newTestRelease("v1.3.0", base.Add(4*time.Hour), true),
+		newTestRelease("v1.2.0", base.Add(3*time.Hour), false),
+		newTestRelease("v1.1.0", base.Add(2*time.Hour), false),
+		newTestRelease("v1.0.0", base.Add(1*time.Hour), false),
+	})
+	if err != nil {
+		t.Fatalf("ReplaceAll: %v", err)
+	}
+	assertReleaseTags(t, repository, 0, 0, "v1.2.0", "v1.1.0", "v1.0.0")
+
+	stale, err = repository.IsStale()
+	if err != nil || stale {
+		t.Fatalf("IsStale after ReplaceAll = %v, %v, want false, nil", stale, err)
+	}
+
+	version := assertVersionChanged(t, repository, "")
+	if unchanged, _ := repository.Version(); unchanged != version {
+		t.Fatalf("Version changed without writes, %q became %q", version, unchanged)
+	}
+
+	paginationTests := []struct {
+		offset int
+		size   int
+		want   []string
+	}{
+		{offset: 0, size: 2, want: []string{"v1.2.0", "v1.1.0"}},
+		{offset: 1, size: 1, want: []string{"v1.1.0"}},
+		{offset: 2, size: 5, want: []string{"v1.0.0"}},
+		{offset: 1, size: 0, want: []string{"v1.1.0", "v1.0.0"}},
+		{offset: 3, size: 1, want: []string{}},
+		{offset: 10, size: 0, want: []string{}},
+	}
+	for _, tt := range paginationTests {
+		assertReleaseTags(t, repository, tt.offset, tt.size, tt.want...)
+	}
+
+	err = repository.Save(newTestRelease("v1.4.0", base.Add(5*time.Hour), false))
+	if err != nil {
+		t.Fatalf("Save new release: %v", err)
+	}
+	assertReleaseTags(t, repository, 0, 0, "v1.4.0", "v1.2.0", "v1.1.0", "v1.0.0")
+	version = assertVersionChanged(t, repository, version)
+
+	edited := newTestRelease("v1.1.0", base.Add(2*time.Hour), false)
+	edited.Body = "## Bugs\n- edited"
+	err = repository.Save(edited)
+	if err != nil {
+		t.Fatalf("Save existing release: %v", err)
+	}
+	assertReleaseTags(t, repository, 0, 0, "v1.4.0", "v1.2.0", "v1.1.0", "v1.0.0")
+	if body := getReleaseBody(t, repository, "v1.1.0"); body != edited.Body {
+		t.Fatalf("body of saved release = %q, want %q", body, edited.Body)
+	}
+	version = assertVersionChanged(t, repository, version)
+
+	err = repository.Save(newTestRelease("v1.0.0", base.Add(1*time.Hour), true))
+	if err != nil {
+		t.Fatalf("Save release as draft: %v", err)
+	}
+	assertReleaseTags(t, repository, 0, 0, "v1.4.0", "v1.2.0", "v1.1.0")
+
+	err = repository.Delete("v1.2.0")
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	err = repository.Delete("v0.0.1")
+	if err != nil {
+		t.Fatalf("Delete of missing release: %v", err)
+	}
+	assertReleaseTags(t, repository, 0, 0, "v1.4.0", "v1.1.0")
+	assertVersionChanged(t, repository, version)
+
+	err = repository.ReplaceAll([]*common.Release{
+		newTestRelease("v2.0.0", base.Add(6*time.Hour), false),
+		newTestRelease("v1.1.0", base.Add(2*time.Hour), false),
+	})
+	if err != nil {
+		t.Fatalf("ReplaceAll: %v", err)
+	}
+	assertReleaseTags(t, repository, 0, 0, "v2.0.0", "v1.1.0")
+
+	if !shared {
+		return
+	}
+	replica := newRepository()
+	stale, err = replica.IsStale()
+	if err != nil || stale {
+		t.Fatalf("IsStale of replica = %v, %v, want false, nil", stale, err)
+	}
+	assertReleaseTags(t, replica, 0, 0, "v2.0.0", "v1.1.0")
+
+	// edit of an older release by a replica does not change latest tag but must still be picked up
+	edited = newTestRelease("v1.1.0", base.Add(2*time.Hour), false)
+	edited.Body = "## Bugs\n- edited by replica"
+	err = replica.Save(edited)
+	if err != nil {
+		t.Fatalf("Save by replica: %v", err)
+	}
+	stale, err = repository.IsStale()
+	if err != nil || stale {
+		t.Fatalf("IsStale after edit by replica = %v, %v, want false, nil", stale, err)
+	}
+	if body := getReleaseBody(t, repository, "v1.1.0"); body != edited.Body {
+		t.Fatalf("body after edit by replica = %q, want %q", body, edited.Body)
+	}
+	err = replica.Delete("v1.1.0")
+	if err != nil {
+		t.Fatalf("Delete by replica: %v", err)
+	}
+	_, err = repository.IsStale()
+	if err != nil {
+		t.Fatalf("IsStale after delete by replica: %v", err)
+	}
+	assertReleaseTags(t, repository, 0, 0, "v2.0.0")
+}
+
+func assertReleaseTags(t *testing.T, repository ReleaseRepository, offset int, size int, want ...string) {
+	t.Helper()
+	releases, err := repository.List(offset, size)
+	if err != nil {
+		t.Fatalf("List(%d, %d): %v", offset, size, err)
+	}
+	got := make([]string, 0, len(releases))
+	for _, release := range releases {
+		got = append(got, release.TagName)
+	}
+	if want == nil {
+		want = []string{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("List(%d, %d) = %v, want %v", offset, size, got, want)
+	}
+}
+
+// assertVersionChanged returns current version of repository after checking that it is not previous
+func assertVersionChanged(t *testing.T, repository ReleaseRepository, previous string) string {
+	t.Helper()
+	version, err := repository.Version()
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if version == previous {
+		t.Fatalf("Version = %q, want it changed after write", version)
+	}
+	return version
+}
+
+func getReleaseBody(t *testing.T, repository ReleaseRepository, tagName string) string {
+	t.Helper()
+	releases, err := repository.List(0, 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for _, release := range releases {
+		if release.TagName == tagName {
+			return release.Body
+		}
+	}
+	t.Fatalf("release %s not found", tagName)
+	return ""
+}
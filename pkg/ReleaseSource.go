@@ -0,0 +1,244 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/google/go-github/github"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const releaseSourceHttpTimeout = 30 * time.Second
+
+// ReleaseEvent is a release webhook event of any provider, Action is one of Action* constants. Repo is full name
+// of the repository in payload, it is empty when payload does not have one.
+type ReleaseEvent struct {
+	Action  string
+	Repo    string
+	Release *common.Release
+}
+
+// ReleaseSource is the git provider releases are read from and release webhooks are received from.
+// Releases are returned as provider sent them, prerequisite and sections are filled by ReleaseNoteService.
+type ReleaseSource interface {
+	Provider() string
+	// Repos returns owner/repo full names of repos releases are read from, primary repo first
+	Repos() []string
+	// ListReleases fetches releases of repo including drafts latest first, up to ReleasesMaxCount. Repo is one of Repos.
+	ListReleases(repo string) ([]*common.Release, error)
+	// ReleasesMaxCount is the number of releases ListReleases is cut at, 0 means every release is fetched
+	ReleasesMaxCount() int
+	// IssueBaseUrl returns web url under which issues of repo are linked by number, empty if it is not known
+	IssueBaseUrl(repo string) string
+	// ValidateWebhook verifies delivery with the provider's webhook secret scheme
+	ValidateWebhook(r *http.Request, requestBodyBytes []byte) bool
+	// GetWebhookEventType returns event type in github naming, release events are EventTypeRelease for every provider
+	GetWebhookEventType(r *http.Request) string
+	GetWebhookDeliveryId(r *http.Request) string
+	// WebhookCredentialHeaders returns request headers carrying the webhook secret, they are never stored
+	WebhookCredentialHeaders() []string
+	// DecodeReleaseEvent decodes release webhook payload, returns *InvalidPayloadError on bad input
+	DecodeReleaseEvent(requestBodyBytes []byte) (*ReleaseEvent, error)
+}
+
+// NewReleaseSource returns release source of configured provider
+func NewReleaseSource(logger *zap.SugaredLogger, config *util.ReleaseSourceConfig, client *util.GitHubClient,
+	webhookSecretValidator WebhookSecretValidator) (ReleaseSource, error) {
+	switch config.Provider {
+	case util.GITHUB_PROVIDER:
+		return NewGitHubReleaseSourceImpl(logger, client, webhookSecretValidator), nil
+	case util.GITLAB_PROVIDER:
+		return NewGitLabReleaseSourceImpl(logger, config), nil
+	case util.GITEA_PROVIDER:
+		return NewGiteaReleaseSourceImpl(logger, config), nil
+	}
+	return nil, fmt.Errorf("unsupported release source provider %s", config.Provider)
+}
+
+type GitHubReleaseSourceImpl struct {
+	logger                 *zap.SugaredLogger
+	client                 *util.GitHubClient
+	webhookSecretValidator WebhookSecretValidator
+}
+
+func NewGitHubReleaseSourceImpl(logger *zap.SugaredLogger, client *util.GitHubClient, webhookSecretValidator WebhookSecretValidator) *GitHubReleaseSourceImpl {
+	return &GitHubReleaseSourceImpl{
+		logger:                 logger,
+		client:                 client,
+		webhookSecretValidator: webhookSecretValidator,
+	}
+}
+
+func (impl *GitHubReleaseSourceImpl) Provider() string {
+	return util.GITHUB_PROVIDER
+}
+
+func (impl *GitHubReleaseSourceImpl) Repos() []string {
+	return impl.client.GitHubConfig.GetRepoFullNames()
+}
+
+// ListReleases follows the NextPage cursor until all the pages are read or GitHubReleasesMaxCount
+// releases are collected
+func (impl *GitHubReleaseSourceImpl) ListReleases(repo string) ([]*common.Release, error) {
+	owner, name := util.SplitRepoFullName(repo)
+	perPage := impl.client.GitHubConfig.GitHubReleasesPerPage
+	if perPage <= 0 || perPage > MaxReleasesPerPage {
+		perPage = MaxReleasesPerPage
+	}
+	maxCount := impl.ReleasesMaxCount()
+	listOptions := &github.ListOptions{PerPage: perPage}
+	var releases []*common.Release
+	for {
+		pageReleases, response, err := impl.client.GitHubClient.Repositories.ListReleases(context.Background(), owner, name, listOptions)
+		if err != nil {
+			return releases, err
+		}
+		for _, item := range pageReleases {
+			if item == nil {
+				impl.logger.Warnw("empty release in github response, skipping")
+				continue
+			}
+			releases = append(releases, impl.adaptRelease(repo, item))
+		}
+		if isReleasesMaxCountReached(impl.logger, releases, maxCount) {
+			releases = releases[:maxCount]
+			break
+		}
+		if response == nil || response.NextPage == 0 {
+			break
+		}
+		listOptions.Page = response.NextPage
+	}
+	return releases, nil
+}
+
+func (impl *GitHubReleaseSourceImpl) ReleasesMaxCount() int {
+	return impl.client.GitHubConfig.GitHubReleasesMaxCount
+}
+
+func (impl *GitHubReleaseSourceImpl) ValidateWebhook(r *http.Request, requestBodyBytes []byte) bool {
+	return impl.webhookSecretValidator.ValidateSecret(r, requestBodyBytes)
+}
+
+func (impl *GitHubReleaseSourceImpl) GetWebhookEventType(r *http.Request) string {
+	return r.Header.Get(impl.client.GitHubConfig.GitHubEventTypeHeader)
+}
+
+// IssueBaseUrl returns issues url of repo, github redirects issue links to pull requests as both share
+// the same number space
+func (impl *GitHubReleaseSourceImpl) IssueBaseUrl(repo string) string {
+	if owner, _ := util.SplitRepoFullName(repo); len(owner) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/issues", strings.TrimSuffix(impl.client.GitHubConfig.GitHubHost, "/"), repo)
+}
+
+func (impl *GitHubReleaseSourceImpl) GetWebhookDeliveryId(r *http.Request) string {
+	return r.Header.Get(impl.client.GitHubConfig.GitHubDeliveryHeader)
+}
+
+// WebhookCredentialHeaders returns the secret header of plain text validator, signatures do not reveal the secret
+func (impl *GitHubReleaseSourceImpl) WebhookCredentialHeaders() []string {
+	if impl.client.GitHubConfig.GitHubSecretValidator == SECRET_VALIDATOR_PLAIN_TEXT {
+		return []string{impl.client.GitHubConfig.GitHubSecretHeader}
+	}
+	return nil
+}
+
+func (impl *GitHubReleaseSourceImpl) DecodeReleaseEvent(requestBodyBytes []byte) (*ReleaseEvent, error) {
+	releaseEvent, err := DecodeGitHubReleaseEvent(requestBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	repo := releaseEvent.GetRepo().GetFullName()
+	tagLinkRepo := repo
+	if len(tagLinkRepo) == 0 {
+		// hand crafted payloads without repository are applied to primary repo
+		tagLinkRepo = impl.Repos()[0]
+	}
+	return &ReleaseEvent{
+		Action:  releaseEvent.GetAction(),
+		Repo:    repo,
+		Release: impl.adaptRelease(tagLinkRepo, releaseEvent.Release),
+	}, nil
+}
+
+// adaptRelease links release to tag page of repo on configured github host
+func (impl *GitHubReleaseSourceImpl) adaptRelease(repo string, item *github.RepositoryRelease) *common.Release {
+	release := adaptGithubRelease(item)
+	if len(release.TagName) > 0 {
+		release.TagLink = fmt.Sprintf("%s/%s/releases/tag/%s", strings.TrimSuffix(impl.client.GitHubConfig.GitHubHost, "/"), repo, release.TagName)
+	}
+	return release
+}
+
+// adaptGithubRelease maps github release to common.Release, missing fields and TagLink are left empty
+func adaptGithubRelease(item *github.RepositoryRelease) *common.Release {
+	var createdAt, publishedAt time.Time
+	if item.CreatedAt != nil {
+		createdAt = item.CreatedAt.Time
+	}
+	if item.PublishedAt != nil {
+		publishedAt = item.PublishedAt.Time
+	}
+	return &common.Release{
+		TagName:     item.GetTagName(),
+		ReleaseName: item.GetName(),
+		CreatedAt:   createdAt,
+		PublishedAt: publishedAt,
+		Body:        item.GetBody(),
+		Prerelease:  item.GetPrerelease(),
+		Draft:       item.GetDraft(),
+	}
+}
+
+// getJson sends GET request and decodes json response into v, non 2xx responses are *util.HttpStatusError
+// isReleasesMaxCountReached is true when releases fetched so far reach maxCount and remaining pages are to be skipped
+func isReleasesMaxCountReached(logger *zap.SugaredLogger, releases []*common.Release, maxCount int) bool {
+	if maxCount <= 0 || len(releases) < maxCount {
+		return false
+	}
+	logger.Infow("reached max release count limit, skipping remaining pages", "maxCount", maxCount)
+	return true
+}
+
+func getJson(httpClient *http.Client, url string, header http.Header, v interface{}) (http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.Header, &util.HttpStatusError{StatusCode: resp.StatusCode, Header: resp.Header, Url: req.URL.Redacted()}
+	}
+	return resp.Header, json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	util "github.com/devtron-labs/central-api/client"
+	"go.uber.org/zap"
+)
+
+// newPagedReleaseServer serves endless pages of pageSize releases, next page header is set when not empty
+func newPagedReleaseServer(t *testing.T, pageSize int, nextPageHeader string) (*httptest.Server, *int) {
+	requestedPages := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPages++
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil {
+			t.Errorf("invalid page %q", r.URL.Query().Get("page"))
+		}
+		releases := make([]map[string]string, 0, pageSize)
+		for i := 0; i < pageSize; i++ {
+			releases = append(releases, map[string]string{"tag_name": fmt.Sprintf("v1.%d.%d", page, i)})
+		}
+		if len(nextPageHeader) > 0 {
+			w.Header().Set(nextPageHeader, strconv.Itoa(page+1))
+		}
+		_ = json.NewEncoder(w).Encode(releases)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requestedPages
+}
+
+func TestListReleasesStopsAtMaxCount(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	gitLabServer, gitLabPages := newPagedReleaseServer(t, 20, gitLabNextPageHeader)
+	giteaServer, giteaPages := newPagedReleaseServer(t, giteaReleasesPerPage, "")
+	tests := []struct {
+		name          string
+		releaseSource ReleaseSource
+		requestedPage *int
+		wantPages     int
+	}{
+		{
+			name: "gitlab",
+			releaseSource: NewGitLabReleaseSourceImpl(logger, &util.ReleaseSourceConfig{
+				GitLabHost: gitLabServer.URL, GitLabProject: "devtron-labs/devtron", ReleasesMaxCount: 50,
+			}),
+			requestedPage: gitLabPages,
+			wantPages:     3,
+		},
+		{
+			name: "gitea",
+			releaseSource: NewGiteaReleaseSourceImpl(logger, &util.ReleaseSourceConfig{
+				GiteaHost: giteaServer.URL, GiteaOwner: "devtron-labs", GiteaRepo: "devtron", ReleasesMaxCount: 120,
+			}),
+			requestedPage: giteaPages,
+			wantPages:     3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releases, err := tt.releaseSource.ListReleases(tt.releaseSource.Repos()[0])
+			if err != nil {
+				t.Fatalf("ListReleases: %v", err)
+			}
+			if len(releases) != tt.releaseSource.ReleasesMaxCount() {
+				t.Errorf("ListReleases() returned %d releases, want %d", len(releases), tt.releaseSource.ReleasesMaxCount())
+			}
+			if *tt.requestedPage != tt.wantPages {
+				t.Errorf("ListReleases() requested %d pages, want %d", *tt.requestedPage, tt.wantPages)
+			}
+		})
+	}
+}
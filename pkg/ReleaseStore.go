@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"github.com/devtron-labs/central-api/common"
+	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+)
+
+// ReleaseStore holds releases served by blob, filesystem and memory backends. Every write builds a new list and swaps it
+// atomically, so readers always see a complete list without locking. Lists and releases returned by the store
+// are shared between readers and must never be modified, copy a release before changing it.
+type ReleaseStore interface {
+	// Get returns releases latest first
+	Get() []*common.Release
+	// Replace swaps the whole list, releases are copied so that caller can keep using its own values
+	Replace(releases []*common.Release) []*common.Release
+	// Upsert replaces release having same tag name or adds it as latest
+	Upsert(release *common.Release) []*common.Release
+	// Remove drops release having tag name, no-op if it is not present
+	Remove(tagName string) []*common.Release
+	// Generation is incremented on every write, list returned by Get is at least as new as the generation read before it
+	Generation() uint64
+}
+
+type ReleaseStoreImpl struct {
+	// generation is incremented after snapshot is swapped, first field to keep 64 bit alignment for atomic access
+	generation uint64
+	logger     *zap.SugaredLogger
+	// snapshot holds []*common.Release, it is only ever swapped and never modified
+	snapshot atomic.Value
+	// writeLock serializes writers so that concurrent read-modify-write do not lose updates, readers never take it
+	writeLock sync.Mutex
+}
+
+func NewReleaseStoreImpl(logger *zap.SugaredLogger) *ReleaseStoreImpl {
+	impl := &ReleaseStoreImpl{logger: logger}
+	impl.snapshot.Store([]*common.Release{})
+	return impl
+}
+
+func (impl *ReleaseStoreImpl) Get() []*common.Release {
+	releases := impl.snapshot.Load().([]*common.Release)
+	// capacity is capped so that appends by readers never write into shared backing array
+	return releases[:len(releases):len(releases)]
+}
+
+func (impl *ReleaseStoreImpl) Replace(releases []*common.Release) []*common.Release {
+	impl.writeLock.Lock()
+	defer impl.writeLock.Unlock()
+	snapshot := make([]*common.Release, 0, len(releases))
+	for _, release := range releases {
+		if release != nil {
+			snapshot = append(snapshot, cloneRelease(release))
+		}
+	}
+	return impl.publish(snapshot)
+}
+
+func (impl *ReleaseStoreImpl) Upsert(release *common.Release) []*common.Release {
+	impl.writeLock.Lock()
+	defer impl.writeLock.Unlock()
+	current := impl.Get()
+	stored := cloneRelease(release)
+	snapshot := make([]*common.Release, 0, len(current)+1)
+	isNew := true
+	for _, existing := range current {
+		if existing.TagName == stored.TagName {
+			snapshot = append(snapshot, stored)
+			isNew = false
+			continue
+		}
+		snapshot = append(snapshot, existing)
+	}
+	if isNew {
+		snapshot = append([]*common.Release{stored}, snapshot...)
+	}
+	return impl.publish(snapshot)
+}
+
+func (impl *ReleaseStoreImpl) Remove(tagName string) []*common.Release {
+	impl.writeLock.Lock()
+	defer impl.writeLock.Unlock()
+	current := impl.Get()
+	snapshot := make([]*common.Release, 0, len(current))
+	for _, existing := range current {
+		if existing.TagName != tagName {
+			snapshot = append(snapshot, existing)
+		}
+	}
+	return impl.publish(snapshot)
+}
+
+func (impl *ReleaseStoreImpl) Generation() uint64 {
+	return atomic.LoadUint64(&impl.generation)
+}
+
+func (impl *ReleaseStoreImpl) publish(snapshot []*common.Release) []*common.Release {
+	impl.snapshot.Store(snapshot)
+	atomic.AddUint64(&impl.generation, 1)
+	impl.logger.Debugw("release store updated", "count", len(snapshot))
+	return snapshot[:len(snapshot):len(snapshot)]
+}
+
+// cloneRelease deep copies release so that later changes to the source are not visible in store
+func cloneRelease(release *common.Release) *common.Release {
+	clone := *release
+	if release.Sections != nil {
+		clone.Sections = make([]*common.ReleaseSection, 0, len(release.Sections))
+		for _, section := range release.Sections {
+			if section == nil {
+				continue
+			}
+			sectionClone := *section
+			sectionClone.Items = make([]*common.ReleaseSectionItem, 0, len(section.Items))
+			for _, item := range section.Items {
+				if item == nil {
+					continue
+				}
+				itemClone := *item
+				itemClone.PrNumbers = append([]int(nil), item.PrNumbers...)
+				itemClone.Authors = append([]string(nil), item.Authors...)
+				sectionClone.Items = append(sectionClone.Items, &itemClone)
+			}
+			clone.Sections = append(clone.Sections, &sectionClone)
+		}
+	}
+	return &clone
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/devtron-labs/central-api/common"
+	"go.uber.org/zap"
+)
+
+func newStoreRelease(tagName string, body string) *common.Release {
+	return &common.Release{
+		TagName: tagName,
+		Body:    body,
+		Sections: []*common.ReleaseSection{{
+			Title: "Bugs",
+			Items: []*common.ReleaseSectionItem{{Text: body, PrNumbers: []int{1}, Authors: []string{"octocat"}}},
+		}},
+	}
+}
+
+func getStoreTags(releases []*common.Release) []string {
+	tags := make([]string, 0, len(releases))
+	for _, release := range releases {
+		tags = append(tags, release.TagName)
+	}
+	return tags
+}
+
+func TestReleaseStoreOrder(t *testing.T) {
+	releaseStore := NewReleaseStoreImpl(zap.NewNop().Sugar())
+	releaseStore.Replace([]*common.Release{newStoreRelease("v1.1.0", "b"), nil, newStoreRelease("v1.0.0", "a")})
+	releaseStore.Upsert(newStoreRelease("v1.2.0", "c"))
+	releaseStore.Upsert(newStoreRelease("v1.1.0", "b edited"))
+	releaseStore.Remove("v1.0.0")
+	releaseStore.Remove("v0.0.1")
+
+	got := releaseStore.Get()
+	if want := []string{"v1.2.0", "v1.1.0"}; !reflect.DeepEqual(getStoreTags(got), want) {
+		t.Fatalf("Get = %v, want %v", getStoreTags(got), want)
+	}
+	if got[1].Body != "b edited" {
+		t.Fatalf("body of upserted release = %q, want %q", got[1].Body, "b edited")
+	}
+}
+
+func TestReleaseStoreSnapshotIsNotChangedByWrites(t *testing.T) {
+	releaseStore := NewReleaseStoreImpl(zap.NewNop().Sugar())
+	input := []*common.Release{newStoreRelease("v1.1.0", "b"), newStoreRelease("v1.0.0", "a")}
+	releaseStore.Replace(input)
+	snapshot := releaseStore.Get()
+
+	// changes of caller's values are not visible in store
+	input[0].Body = "changed by caller"
+	input[0].Sections[0].Items[0].PrNumbers[0] = 2
+
+	releaseStore.Upsert(newStoreRelease("v1.2.0", "c"))
+	releaseStore.Upsert(newStoreRelease("v1.1.0", "b edited"))
+	releaseStore.Remove("v1.0.0")
+	releaseStore.Replace([]*common.Release{newStoreRelease("v2.0.0", "d")})
+	// appends by a reader never write into the backing array of another reader
+	_ = append(releaseStore.Get(), newStoreRelease("v3.0.0", "e"))
+	_ = append(snapshot, newStoreRelease("v3.0.0", "e"))
+
+	if want := []string{"v1.1.0", "v1.0.0"}; !reflect.DeepEqual(getStoreTags(snapshot), want) {
+		t.Fatalf("snapshot = %v, want %v", getStoreTags(snapshot), want)
+	}
+	if snapshot[0].Body != "b" {
+		t.Fatalf("body in snapshot = %q, want %q", snapshot[0].Body, "b")
+	}
+	if prNumber := snapshot[0].Sections[0].Items[0].PrNumbers[0]; prNumber != 1 {
+		t.Fatalf("pr number in snapshot = %d, want 1", prNumber)
+	}
+	if want := []string{"v2.0.0"}; !reflect.DeepEqual(getStoreTags(releaseStore.Get()), want) {
+		t.Fatalf("Get = %v, want %v", getStoreTags(releaseStore.Get()), want)
+	}
+}
+
+// TestReleaseStoreConcurrentAccess is meant to be run with -race, every reader checks that the snapshot
+// it got stays the same while writers keep changing the store
+func TestReleaseStoreConcurrentAccess(t *testing.T) {
+	releaseStore := NewReleaseStoreImpl(zap.NewNop().Sugar())
+	releaseStore.Replace([]*common.Release{newStoreRelease("v1.0.0", "a")})
+	const writers = 4
+	const readers = 4
+	const rounds = 200
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				tagName := fmt.Sprintf("v%d.%d.0", writer, round)
+				switch round % 4 {
+				case 0, 1:
+					releaseStore.Upsert(newStoreRelease(tagName, "upserted"))
+				case 2:
+					releaseStore.Remove(fmt.Sprintf("v%d.%d.0", writer, round-1))
+				case 3:
+					releaseStore.Replace([]*common.Release{newStoreRelease(tagName, "replaced"), newStoreRelease("v1.0.0", "a")})
+				}
+			}
+		}(i)
+	}
+	errs := make(chan error, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				snapshot := releaseStore.Get()
+				tags := getStoreTags(snapshot)
+				bodies := make([]string, 0, len(snapshot))
+				for _, release := range snapshot {
+					bodies = append(bodies, release.Body)
+				}
+				_ = append(snapshot, newStoreRelease("reader", "appended"))
+				for j, release := range snapshot {
+					if release.TagName != tags[j] || release.Body != bodies[j] {
+						errs <- fmt.Errorf("snapshot changed at %d, %s %q became %s %q", j, tags[j], bodies[j], release.TagName, release.Body)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/google/go-github/github"
+	"strings"
+)
+
+const MaxTagNameLength = 250
+
+// InvalidPayloadError is returned when webhook payload can not be decoded or fails field validation
+type InvalidPayloadError struct {
+	FieldErrors []*common.FieldError
+}
+
+func (e *InvalidPayloadError) Error() string {
+	var messages []string
+	for _, fieldError := range e.FieldErrors {
+		messages = append(messages, fmt.Sprintf("%s: %s", fieldError.Field, fieldError.Message))
+	}
+	return "invalid webhook payload, " + strings.Join(messages, ", ")
+}
+
+func (e *InvalidPayloadError) addFieldError(field string, message string) {
+	e.FieldErrors = append(e.FieldErrors, &common.FieldError{Field: field, Message: message})
+}
+
+// DecodeGitHubReleaseEvent decodes github release webhook payload and validates fields needed to apply it,
+// returns *InvalidPayloadError listing every invalid field on bad input. Gitea sends the same payload.
+func DecodeGitHubReleaseEvent(requestBodyBytes []byte) (*github.ReleaseEvent, error) {
+	releaseEvent := &github.ReleaseEvent{}
+	invalidPayloadError := decodePayload(requestBodyBytes, releaseEvent)
+	if len(invalidPayloadError.FieldErrors) > 0 {
+		return nil, invalidPayloadError
+	}
+	if len(releaseEvent.GetAction()) == 0 {
+		invalidPayloadError.addFieldError("action", "required")
+	}
+	release := releaseEvent.Release
+	if release == nil {
+		invalidPayloadError.addFieldError("release", "required")
+	} else {
+		validateTagName(invalidPayloadError, "release.tag_name", release.GetTagName())
+	}
+	if len(invalidPayloadError.FieldErrors) > 0 {
+		return nil, invalidPayloadError
+	}
+	return releaseEvent, nil
+}
+
+func validateTagName(invalidPayloadError *InvalidPayloadError, field string, tagName string) {
+	tagName = strings.TrimSpace(tagName)
+	if len(tagName) == 0 {
+		invalidPayloadError.addFieldError(field, "required")
+	} else if len(tagName) > MaxTagNameLength {
+		invalidPayloadError.addFieldError(field, fmt.Sprintf("must not be longer than %d characters", MaxTagNameLength))
+	}
+}
+
+// DecodePingEvent decodes ping payload sent by github when webhook is created
+func DecodePingEvent(requestBodyBytes []byte) (*common.WebhookPingResponse, error) {
+	pingEvent := &github.PingEvent{}
+	invalidPayloadError := decodePayload(requestBodyBytes, pingEvent)
+	if len(invalidPayloadError.FieldErrors) > 0 {
+		return nil, invalidPayloadError
+	}
+	pingResponse := &common.WebhookPingResponse{
+		HookId:     pingEvent.GetHookID(),
+		Zen:        pingEvent.GetZen(),
+		HookEvents: []string{},
+	}
+	if pingEvent.Hook != nil {
+		pingResponse.HookEvents = pingEvent.Hook.Events
+	}
+	return pingResponse, nil
+}
+
+// decodePayload unmarshals payload into event, json errors are reported as field errors
+func decodePayload(requestBodyBytes []byte, event interface{}) *InvalidPayloadError {
+	invalidPayloadError := &InvalidPayloadError{}
+	err := json.Unmarshal(requestBodyBytes, event)
+	if err != nil {
+		var typeError *json.UnmarshalTypeError
+		var syntaxError *json.SyntaxError
+		switch {
+		case errors.As(err, &typeError):
+			invalidPayloadError.addFieldError(typeError.Field, fmt.Sprintf("expected %s but got %s", typeError.Type.String(), typeError.Value))
+		case errors.As(err, &syntaxError):
+			invalidPayloadError.addFieldError("payload", fmt.Sprintf("malformed json at offset %d", syntaxError.Offset))
+		default:
+			// timestamps in unexpected format end up here
+			invalidPayloadError.addFieldError("payload", err.Error())
+		}
+	}
+	return invalidPayloadError
+}
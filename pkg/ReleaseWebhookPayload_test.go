@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/devtron-labs/central-api/common"
+)
+
+const releaseWebhookFixtureDir = "testdata/release_webhook"
+
+func TestDecodeGitHubReleaseEvent(t *testing.T) {
+	tests := []struct {
+		fixture         string
+		wantAction      string
+		wantTagName     string
+		wantNullBody    bool
+		wantPublished   bool
+		wantFieldErrors []*common.FieldError
+	}{
+		{fixture: "published.json", wantAction: "published", wantTagName: "v0.7.2", wantPublished: true},
+		{fixture: "edited.json", wantAction: "edited", wantTagName: "v0.7.2", wantPublished: true},
+		{fixture: "deleted.json", wantAction: "deleted", wantTagName: "v0.7.2", wantPublished: true},
+		{fixture: "prereleased.json", wantAction: "prereleased", wantTagName: "v0.7.3-rc.1", wantPublished: true},
+		{fixture: "unpublished.json", wantAction: "unpublished", wantTagName: "v0.7.2"},
+		// null body and missing timestamps are valid, they are left empty instead of failing the delivery
+		{fixture: "null_body.json", wantAction: "published", wantTagName: "v0.7.2", wantNullBody: true, wantPublished: true},
+		{fixture: "missing_timestamps.json", wantAction: "published", wantTagName: "v0.7.2"},
+		{fixture: "malformed.json", wantFieldErrors: []*common.FieldError{
+			{Field: "payload", Message: "malformed json at offset 80"},
+		}},
+		{fixture: "invalid_tag_name_type.json", wantFieldErrors: []*common.FieldError{
+			{Field: "release.tag_name", Message: "expected string but got number"},
+		}},
+		{fixture: "missing_tag_name_and_action.json", wantFieldErrors: []*common.FieldError{
+			{Field: "action", Message: "required"},
+			{Field: "release.tag_name", Message: "required"},
+		}},
+	}
+
+	fixtures, err := filepath.Glob(filepath.Join(releaseWebhookFixtureDir, "*.json"))
+	if err != nil {
+		t.Fatalf("error in listing fixtures: %v", err)
+	}
+	covered := make([]string, 0, len(tests))
+	for _, tt := range tests {
+		covered = append(covered, tt.fixture)
+	}
+	for i := range fixtures {
+		fixtures[i] = filepath.Base(fixtures[i])
+	}
+	sort.Strings(fixtures)
+	sort.Strings(covered)
+	if !reflect.DeepEqual(fixtures, covered) {
+		t.Fatalf("fixtures %v are not all covered by test cases %v", fixtures, covered)
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(strings.TrimSuffix(tt.fixture, ".json"), func(t *testing.T) {
+			payload, err := os.ReadFile(filepath.Join(releaseWebhookFixtureDir, tt.fixture))
+			if err != nil {
+				t.Fatalf("error in reading fixture: %v", err)
+			}
+			releaseEvent, err := DecodeGitHubReleaseEvent(payload)
+			if tt.wantFieldErrors != nil {
+				invalidPayloadError, ok := err.(*InvalidPayloadError)
+				if !ok {
+					t.Fatalf("error = %v, want *InvalidPayloadError", err)
+				}
+				if !reflect.DeepEqual(invalidPayloadError.FieldErrors, tt.wantFieldErrors) {
+					t.Fatalf("field errors = %s, want %s", formatFieldErrors(invalidPayloadError.FieldErrors), formatFieldErrors(tt.wantFieldErrors))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if releaseEvent.GetAction() != tt.wantAction {
+				t.Errorf("action = %q, want %q", releaseEvent.GetAction(), tt.wantAction)
+			}
+			if releaseEvent.Release.GetTagName() != tt.wantTagName {
+				t.Errorf("tag name = %q, want %q", releaseEvent.Release.GetTagName(), tt.wantTagName)
+			}
+			if nullBody := releaseEvent.Release.Body == nil; nullBody != tt.wantNullBody {
+				t.Errorf("body is null = %v, want %v", nullBody, tt.wantNullBody)
+			}
+			if published := releaseEvent.Release.PublishedAt != nil; published != tt.wantPublished {
+				t.Errorf("published at set = %v, want %v", published, tt.wantPublished)
+			}
+		})
+	}
+}
+
+func formatFieldErrors(fieldErrors []*common.FieldError) string {
+	messages := make([]string, 0, len(fieldErrors))
+	for _, fieldError := range fieldErrors {
+		messages = append(messages, fieldError.Field+": "+fieldError.Message)
+	}
+	return "[" + strings.Join(messages, ", ") + "]"
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"errors"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/internal/semver"
+	"go.uber.org/zap"
+)
+
+var ErrUpgradeTargetNotFound = errors.New("target version not found in releases")
+
+type UpgradePathService interface {
+	GetUpgradePath(current string, target string) (*common.UpgradePath, error)
+}
+
+type UpgradePathServiceImpl struct {
+	logger             *zap.SugaredLogger
+	releaseNoteService ReleaseNoteService
+}
+
+func NewUpgradePathServiceImpl(logger *zap.SugaredLogger, releaseNoteService ReleaseNoteService) *UpgradePathServiceImpl {
+	return &UpgradePathServiceImpl{
+		logger:             logger,
+		releaseNoteService: releaseNoteService,
+	}
+}
+
+// GetUpgradePath computes the versions an install on current has to pass through to reach target.
+// Every release marked with prerequisites between current and target is a mandatory stop, empty
+// target means latest stable release.
+func (impl *UpgradePathServiceImpl) GetUpgradePath(current string, target string) (*common.UpgradePath, error) {
+	if len(current) == 0 {
+		return nil, &InvalidVersionError{Param: "current", Message: "version is required"}
+	}
+	currentVersion, err := semver.Parse(current)
+	if err != nil {
+		return nil, &InvalidVersionError{Param: "current", Message: err.Error()}
+	}
+	if len(target) == 0 {
+		target, err = impl.getLatestStableTag()
+		if err != nil {
+			return nil, err
+		}
+	}
+	upgradePath := &common.UpgradePath{
+		Current:           current,
+		Target:            target,
+		DirectUpgradeSafe: true,
+		Hops:              []*common.UpgradeHop{},
+	}
+	if len(target) == 0 {
+		return upgradePath, nil
+	}
+	targetVersion, err := semver.Parse(target)
+	if err != nil {
+		return nil, &InvalidVersionError{Param: "target", Message: err.Error()}
+	}
+	if targetVersion.Compare(currentVersion) <= 0 {
+		// already on target or ahead of it, nothing to upgrade
+		return upgradePath, nil
+	}
+	releaseRange, err := impl.releaseNoteService.GetReleasesInRange(current, target)
+	if err != nil {
+		impl.logger.Errorw("error in getting releases in range", "current", current, "target", target, "err", err)
+		return nil, err
+	}
+	// latest release of the range is below target when target itself is not released
+	if len(releaseRange.Releases) == 0 || !isSameVersion(releaseRange.Releases[0].TagName, targetVersion) {
+		impl.logger.Infow("upgrade target not found in releases", "current", current, "target", target)
+		return nil, ErrUpgradeTargetNotFound
+	}
+	hopFrom := current
+	// releases in range are latest first, walking from the oldest to find mandatory stops
+	for i := len(releaseRange.Releases) - 1; i >= 0; i-- {
+		release := releaseRange.Releases[i]
+		isTarget := i == 0
+		if !release.Prerequisite && !isTarget {
+			continue
+		}
+		if !isTarget {
+			upgradePath.DirectUpgradeSafe = false
+		}
+		upgradePath.Hops = append(upgradePath.Hops, &common.UpgradeHop{
+			From:                hopFrom,
+			To:                  release.TagName,
+			Prerequisite:        release.Prerequisite,
+			PrerequisiteMessage: release.PrerequisiteMessage,
+			TagLink:             release.TagLink,
+		})
+		hopFrom = release.TagName
+	}
+	upgradePath.Target = releaseRange.Releases[0].TagName
+	return upgradePath, nil
+}
+
+func isSameVersion(tagName string, version *semver.Version) bool {
+	tagVersion, err := semver.Parse(tagName)
+	return err == nil && tagVersion.Compare(version) == 0
+}
+
+func (impl *UpgradePathServiceImpl) getLatestStableTag() (string, error) {
+	releases, err := impl.releaseNoteService.GetReleases("", "", 0, 0)
+	if err != nil {
+		impl.logger.Errorw("error in getting releases", "err", err)
+		return "", err
+	}
+	var latestVersion *semver.Version
+	for _, release := range releases {
+		version, err := semver.Parse(release.TagName)
+		if err != nil || version.IsPreRelease() {
+			continue
+		}
+		if latestVersion == nil || version.Compare(latestVersion) > 0 {
+			latestVersion = version
+		}
+	}
+	if latestVersion == nil {
+		return "", nil
+	}
+	return latestVersion.Original, nil
+}
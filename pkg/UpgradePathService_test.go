@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"testing"
+
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/internal/semver"
+	"go.uber.org/zap"
+)
+
+// rangeReleaseNoteService serves ranges out of a fixed list of releases kept latest first
+type rangeReleaseNoteService struct {
+	ReleaseNoteService
+	releases []*common.Release
+}
+
+func (impl *rangeReleaseNoteService) GetReleasesInRange(from string, to string) (*common.ReleaseRange, error) {
+	releaseRange := &common.ReleaseRange{From: from, To: to, Releases: []*common.Release{}}
+	for _, release := range impl.releases {
+		if result, _ := semver.Compare(release.TagName, from); result <= 0 {
+			continue
+		}
+		if result, _ := semver.Compare(release.TagName, to); result > 0 {
+			continue
+		}
+		releaseRange.Releases = append(releaseRange.Releases, release)
+	}
+	return releaseRange, nil
+}
+
+func TestGetUpgradePathTargetNotFound(t *testing.T) {
+	releaseNoteService := &rangeReleaseNoteService{releases: []*common.Release{
+		{TagName: "v0.7.2"},
+		{TagName: "v0.7.0", Prerequisite: true},
+		{TagName: "v0.6.0"},
+	}}
+	upgradePathService := NewUpgradePathServiceImpl(zap.NewNop().Sugar(), releaseNoteService)
+	tests := []struct {
+		current    string
+		target     string
+		wantErr    error
+		wantTarget string
+		wantHops   int
+	}{
+		{current: "v0.6.0", target: "v0.7.2", wantTarget: "v0.7.2", wantHops: 2},
+		{current: "v0.6.0", target: "0.7.2", wantTarget: "v0.7.2", wantHops: 2},
+		{current: "v0.6.0", target: "v0.7.1", wantErr: ErrUpgradeTargetNotFound},
+		{current: "v0.6.0", target: "v0.8.0", wantErr: ErrUpgradeTargetNotFound},
+		{current: "v0.7.2", target: "v0.7.3", wantErr: ErrUpgradeTargetNotFound},
+		{current: "v0.7.2", target: "v0.7.2", wantTarget: "v0.7.2"},
+	}
+	for _, tt := range tests {
+		upgradePath, err := upgradePathService.GetUpgradePath(tt.current, tt.target)
+		if err != tt.wantErr {
+			t.Errorf("GetUpgradePath(%s, %s) error = %v, want %v", tt.current, tt.target, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if upgradePath.Target != tt.wantTarget || len(upgradePath.Hops) != tt.wantHops {
+			t.Errorf("GetUpgradePath(%s, %s) = target %s with %d hops, want %s with %d hops",
+				tt.current, tt.target, upgradePath.Target, len(upgradePath.Hops), tt.wantTarget, tt.wantHops)
+		}
+	}
+}
+
+func TestGetUpgradePathInvalidVersion(t *testing.T) {
+	upgradePathService := NewUpgradePathServiceImpl(zap.NewNop().Sugar(), &rangeReleaseNoteService{})
+	tests := []struct {
+		current   string
+		target    string
+		wantParam string
+	}{
+		{current: "", target: "v0.7.2", wantParam: "current"},
+		{current: "latest", target: "v0.7.2", wantParam: "current"},
+		{current: "v0.6.0", target: "next", wantParam: "target"},
+	}
+	for _, tt := range tests {
+		_, err := upgradePathService.GetUpgradePath(tt.current, tt.target)
+		invalidVersionError, ok := err.(*InvalidVersionError)
+		if !ok {
+			t.Errorf("GetUpgradePath(%q, %q) error = %v, want InvalidVersionError", tt.current, tt.target, err)
+			continue
+		}
+		if invalidVersionError.Param != tt.wantParam {
+			t.Errorf("GetUpgradePath(%q, %q) invalid param = %s, want %s", tt.current, tt.target, invalidVersionError.Param, tt.wantParam)
+		}
+	}
+}
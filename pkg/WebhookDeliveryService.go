@@ -0,0 +1,306 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/pkg/webhookDelivery"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"net/http"
+	"sort"
+	"time"
+)
+
+var ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+var ErrWebhookDeliveryNotReplayable = errors.New("webhook delivery with invalid signature can not be replayed")
+var ErrWebhookEventNotReplayable = errors.New("only release webhook deliveries can be replayed")
+
+// defaultRejectedDeliveryLogSize bounds the rejected delivery log when no log size is configured
+const defaultRejectedDeliveryLogSize = 500
+
+// defaultStaleDeliveryTimeout is used when no stale delivery timeout is configured, it is longer than all the
+// retries of a delivery with default backoff
+const defaultStaleDeliveryTimeout = 10 * time.Minute
+
+// headers which may carry credentials of the sender are never stored, along with credential headers of release source
+var excludedWebhookDeliveryHeaders = []string{"Authorization", "Cookie"}
+
+type WebhookDeliveryService interface {
+	// RecordDelivery stores the received delivery, returns true if same delivery was already received and
+	// is processed or being processed. Failed deliveries, and received or queued ones older than stale delivery
+	// timeout, are processed again on redelivery. Deliveries with
+	// invalid signature are only kept in a bounded in memory log and never change stored deliveries.
+	RecordDelivery(r *http.Request, requestBodyBytes []byte, signatureValid bool) (*webhookDelivery.WebhookDelivery, bool, error)
+	// QueueDelivery marks a recorded delivery as queued for async processing
+	QueueDelivery(delivery *webhookDelivery.WebhookDelivery) error
+	// CompleteDelivery stores the processing outcome of a recorded delivery
+	CompleteDelivery(delivery *webhookDelivery.WebhookDelivery, updated bool, processingErr error)
+	// DeadLetterDelivery marks a delivery which failed on every attempt, it is only processed again on replay or redelivery
+	DeadLetterDelivery(delivery *webhookDelivery.WebhookDelivery, processingErr error)
+	// GetQueuedDeliveries returns deliveries queued but not processed, latest first
+	GetQueuedDeliveries(size int) ([]*webhookDelivery.WebhookDelivery, error)
+	ListDeliveries(offset int, size int, status string) ([]*common.WebhookDelivery, error)
+	// ReplayDelivery applies a stored delivery again through UpdateReleases
+	ReplayDelivery(deliveryId string) (bool, error)
+}
+
+type WebhookDeliveryServiceImpl struct {
+	logger                    *zap.SugaredLogger
+	releaseNoteService        ReleaseNoteService
+	webhookDeliveryRepository webhookDelivery.WebhookDeliveryRepository
+	releaseSource             ReleaseSource
+	// rejectedDeliveries keeps unauthenticated deliveries, anyone can send them so they are never persisted
+	rejectedDeliveries *webhookDelivery.WebhookDeliveryInMemoryRepositoryImpl
+	// staleDeliveryTimeout is the age after which an unprocessed delivery is considered lost
+	staleDeliveryTimeout time.Duration
+}
+
+func NewWebhookDeliveryServiceImpl(logger *zap.SugaredLogger, releaseNoteService ReleaseNoteService,
+	webhookDeliveryRepository webhookDelivery.WebhookDeliveryRepository, releaseSource ReleaseSource,
+	webhookDeliveryConfig *util.WebhookDeliveryConfig) *WebhookDeliveryServiceImpl {
+	return &WebhookDeliveryServiceImpl{
+		logger:                    logger,
+		releaseNoteService:        releaseNoteService,
+		webhookDeliveryRepository: webhookDeliveryRepository,
+		releaseSource:             releaseSource,
+		rejectedDeliveries:        webhookDelivery.NewWebhookDeliveryInMemoryRepositoryImpl(getRejectedDeliveryLogSize(webhookDeliveryConfig)),
+		staleDeliveryTimeout:      getStaleDeliveryTimeout(webhookDeliveryConfig),
+	}
+}
+
+func (impl *WebhookDeliveryServiceImpl) RecordDelivery(r *http.Request, requestBodyBytes []byte, signatureValid bool) (*webhookDelivery.WebhookDelivery, bool, error) {
+	deliveryId := impl.releaseSource.GetWebhookDeliveryId(r)
+	if len(deliveryId) == 0 {
+		// manual deliveries without id can not be deduplicated, they are still recorded for debugging
+		deliveryId = fmt.Sprintf("local-%d", time.Now().UnixNano())
+	}
+	delivery := &webhookDelivery.WebhookDelivery{
+		DeliveryId:     deliveryId,
+		EventType:      impl.releaseSource.GetWebhookEventType(r),
+		Headers:        getStorableHeaders(r.Header, impl.releaseSource.WebhookCredentialHeaders()),
+		SignatureValid: signatureValid,
+		Status:         webhookDelivery.StatusReceived,
+		ReceivedOn:     time.Now(),
+	}
+	if !signatureValid {
+		// body of unauthenticated requests is not stored
+		delivery.Status = webhookDelivery.StatusRejected
+		_, err := impl.rejectedDeliveries.Save(delivery)
+		return delivery, false, err
+	}
+	delivery.Body = string(requestBodyBytes)
+	inserted, err := impl.webhookDeliveryRepository.Save(delivery)
+	if err != nil {
+		impl.logger.Errorw("error in saving webhook delivery", "deliveryId", deliveryId, "err", err)
+		return nil, false, err
+	}
+	if inserted {
+		return delivery, false, nil
+	}
+	existing, err := impl.webhookDeliveryRepository.FindByDeliveryId(deliveryId)
+	if err != nil {
+		impl.logger.Errorw("error in fetching webhook delivery", "deliveryId", deliveryId, "err", err)
+		return nil, false, err
+	}
+	if !isRetryableDelivery(existing, impl.staleDeliveryTimeout) {
+		impl.logger.Infow("duplicate webhook delivery skipped", "deliveryId", deliveryId, "status", existing.Status)
+		return existing, true, nil
+	}
+	impl.logger.Infow("processing redelivery of webhook", "deliveryId", deliveryId, "previousStatus", existing.Status)
+	existing.SignatureValid = delivery.SignatureValid
+	existing.Body = delivery.Body
+	existing.Status = delivery.Status
+	existing.Error = ""
+	existing.Attempts = 0
+	err = impl.webhookDeliveryRepository.Update(existing)
+	if err != nil {
+		impl.logger.Errorw("error in updating webhook delivery", "deliveryId", deliveryId, "err", err)
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+func (impl *WebhookDeliveryServiceImpl) QueueDelivery(delivery *webhookDelivery.WebhookDelivery) error {
+	delivery.Status = webhookDelivery.StatusQueued
+	err := impl.webhookDeliveryRepository.Update(delivery)
+	if err != nil {
+		impl.logger.Errorw("error in queueing webhook delivery", "deliveryId", delivery.DeliveryId, "err", err)
+	}
+	return err
+}
+
+func (impl *WebhookDeliveryServiceImpl) CompleteDelivery(delivery *webhookDelivery.WebhookDelivery, updated bool, processingErr error) {
+	if delivery == nil {
+		return
+	}
+	delivery.ProcessedOn = time.Now()
+	delivery.Error = ""
+	if processingErr != nil {
+		delivery.Status = webhookDelivery.StatusFailed
+		delivery.Error = processingErr.Error()
+	} else if updated {
+		delivery.Status = webhookDelivery.StatusProcessed
+	} else {
+		delivery.Status = webhookDelivery.StatusIgnored
+	}
+	err := impl.webhookDeliveryRepository.Update(delivery)
+	if err != nil {
+		impl.logger.Errorw("error in updating webhook delivery outcome", "deliveryId", delivery.DeliveryId, "err", err)
+	}
+}
+
+func (impl *WebhookDeliveryServiceImpl) DeadLetterDelivery(delivery *webhookDelivery.WebhookDelivery, processingErr error) {
+	delivery.ProcessedOn = time.Now()
+	delivery.Status = webhookDelivery.StatusDeadLetter
+	delivery.Error = processingErr.Error()
+	err := impl.webhookDeliveryRepository.Update(delivery)
+	if err != nil {
+		impl.logger.Errorw("error in moving webhook delivery to dead letter", "deliveryId", delivery.DeliveryId, "err", err)
+	}
+}
+
+func (impl *WebhookDeliveryServiceImpl) GetQueuedDeliveries(size int) ([]*webhookDelivery.WebhookDelivery, error) {
+	return impl.webhookDeliveryRepository.List(0, size, webhookDelivery.StatusQueued)
+}
+
+func (impl *WebhookDeliveryServiceImpl) ListDeliveries(offset int, size int, status string) ([]*common.WebhookDelivery, error) {
+	deliveries, err := impl.listDeliveries(offset, size, status)
+	if err != nil {
+		impl.logger.Errorw("error in listing webhook deliveries", "err", err)
+		return nil, err
+	}
+	response := make([]*common.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, adaptWebhookDelivery(delivery))
+	}
+	return response, nil
+}
+
+// listDeliveries merges stored deliveries with the in memory log of rejected ones, latest first
+func (impl *WebhookDeliveryServiceImpl) listDeliveries(offset int, size int, status string) ([]*webhookDelivery.WebhookDelivery, error) {
+	if len(status) > 0 && status != webhookDelivery.StatusRejected {
+		return impl.webhookDeliveryRepository.List(offset, size, status)
+	}
+	// both sources are read from start as page boundaries depend on the merged order
+	limit := 0
+	if size > 0 {
+		limit = offset + size
+	}
+	deliveries, err := impl.webhookDeliveryRepository.List(0, limit, status)
+	if err != nil {
+		return nil, err
+	}
+	rejectedDeliveries, err := impl.rejectedDeliveries.List(0, limit, status)
+	if err != nil {
+		return nil, err
+	}
+	deliveries = append(deliveries, rejectedDeliveries...)
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].ReceivedOn.After(deliveries[j].ReceivedOn)
+	})
+	if offset >= len(deliveries) {
+		return []*webhookDelivery.WebhookDelivery{}, nil
+	}
+	deliveries = deliveries[offset:]
+	if size > 0 && size < len(deliveries) {
+		deliveries = deliveries[:size]
+	}
+	return deliveries, nil
+}
+
+func (impl *WebhookDeliveryServiceImpl) ReplayDelivery(deliveryId string) (bool, error) {
+	delivery, err := impl.webhookDeliveryRepository.FindByDeliveryId(deliveryId)
+	if err == pg.ErrNoRows {
+		return false, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		impl.logger.Errorw("error in fetching webhook delivery", "deliveryId", deliveryId, "err", err)
+		return false, err
+	}
+	if !delivery.SignatureValid {
+		return false, ErrWebhookDeliveryNotReplayable
+	}
+	if delivery.EventType != EventTypeRelease {
+		return false, ErrWebhookEventNotReplayable
+	}
+	impl.logger.Infow("replaying webhook delivery", "deliveryId", deliveryId, "previousStatus", delivery.Status)
+	updated, processingErr := impl.releaseNoteService.UpdateReleases([]byte(delivery.Body))
+	delivery.ReplayCount++
+	impl.CompleteDelivery(delivery, updated, processingErr)
+	return updated, processingErr
+}
+
+// isRetryableDelivery is true for deliveries which are processed again when github redelivers them. A delivery
+// left received or queued past stale delivery timeout was lost by a crash between saving and processing it.
+func isRetryableDelivery(delivery *webhookDelivery.WebhookDelivery, staleDeliveryTimeout time.Duration) bool {
+	switch delivery.Status {
+	case webhookDelivery.StatusFailed, webhookDelivery.StatusDeadLetter:
+		return true
+	case webhookDelivery.StatusReceived, webhookDelivery.StatusQueued:
+		return time.Since(delivery.ReceivedOn) > staleDeliveryTimeout
+	}
+	return false
+}
+
+func getRejectedDeliveryLogSize(webhookDeliveryConfig *util.WebhookDeliveryConfig) int {
+	if webhookDeliveryConfig == nil || webhookDeliveryConfig.InMemoryLogSize <= 0 {
+		return defaultRejectedDeliveryLogSize
+	}
+	return webhookDeliveryConfig.InMemoryLogSize
+}
+
+func getStaleDeliveryTimeout(webhookDeliveryConfig *util.WebhookDeliveryConfig) time.Duration {
+	if webhookDeliveryConfig == nil || webhookDeliveryConfig.StaleDeliveryTimeout <= 0 {
+		return defaultStaleDeliveryTimeout
+	}
+	return webhookDeliveryConfig.StaleDeliveryTimeout
+}
+
+func getStorableHeaders(header http.Header, credentialHeaders []string) map[string][]string {
+	headers := header.Clone()
+	for _, excludedHeader := range excludedWebhookDeliveryHeaders {
+		headers.Del(excludedHeader)
+	}
+	for _, credentialHeader := range credentialHeaders {
+		headers.Del(credentialHeader)
+	}
+	return headers
+}
+
+func adaptWebhookDelivery(delivery *webhookDelivery.WebhookDelivery) *common.WebhookDelivery {
+	response := &common.WebhookDelivery{
+		DeliveryId:     delivery.DeliveryId,
+		EventType:      delivery.EventType,
+		Headers:        delivery.Headers,
+		Body:           delivery.Body,
+		SignatureValid: delivery.SignatureValid,
+		Status:         delivery.Status,
+		Error:          delivery.Error,
+		ReplayCount:    delivery.ReplayCount,
+		Attempts:       delivery.Attempts,
+		ReceivedOn:     delivery.ReceivedOn,
+	}
+	if !delivery.ProcessedOn.IsZero() {
+		processedOn := delivery.ProcessedOn
+		response.ProcessedOn = &processedOn
+	}
+	return response
+}
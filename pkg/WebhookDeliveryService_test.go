@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/pkg/webhookDelivery"
+	"go.uber.org/zap"
+)
+
+// headerReleaseSource reads delivery id from X-Delivery header
+type headerReleaseSource struct {
+	ReleaseSource
+}
+
+func (impl *headerReleaseSource) GetWebhookEventType(r *http.Request) string {
+	return EventTypeRelease
+}
+
+func (impl *headerReleaseSource) GetWebhookDeliveryId(r *http.Request) string {
+	return r.Header.Get("X-Delivery")
+}
+
+func (impl *headerReleaseSource) WebhookCredentialHeaders() []string {
+	return nil
+}
+
+func TestRecordDeliveryRetriesStaleDeliveries(t *testing.T) {
+	staleDeliveryTimeout := time.Minute
+	tests := []struct {
+		name          string
+		status        string
+		age           time.Duration
+		wantDuplicate bool
+	}{
+		{name: "recent received delivery", status: webhookDelivery.StatusReceived, age: time.Second, wantDuplicate: true},
+		{name: "recent queued delivery", status: webhookDelivery.StatusQueued, age: time.Second, wantDuplicate: true},
+		{name: "received delivery lost by crash", status: webhookDelivery.StatusReceived, age: time.Hour, wantDuplicate: false},
+		{name: "queued delivery lost by crash", status: webhookDelivery.StatusQueued, age: time.Hour, wantDuplicate: false},
+		{name: "processed delivery", status: webhookDelivery.StatusProcessed, age: time.Hour, wantDuplicate: true},
+		{name: "ignored delivery", status: webhookDelivery.StatusIgnored, age: time.Hour, wantDuplicate: true},
+		{name: "failed delivery", status: webhookDelivery.StatusFailed, age: time.Second, wantDuplicate: false},
+		{name: "dead letter delivery", status: webhookDelivery.StatusDeadLetter, age: time.Second, wantDuplicate: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := webhookDelivery.NewWebhookDeliveryInMemoryRepositoryImpl(10)
+			_, err := repository.Save(&webhookDelivery.WebhookDelivery{
+				DeliveryId:     "delivery-1",
+				EventType:      EventTypeRelease,
+				SignatureValid: true,
+				Status:         tt.status,
+				Attempts:       2,
+				ReceivedOn:     time.Now().Add(-tt.age),
+			})
+			if err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			config := &util.WebhookDeliveryConfig{StaleDeliveryTimeout: staleDeliveryTimeout}
+			webhookDeliveryService := NewWebhookDeliveryServiceImpl(zap.NewNop().Sugar(), nil, repository, &headerReleaseSource{}, config)
+
+			r := httptest.NewRequest(http.MethodPost, "/release/webhook", nil)
+			r.Header.Set("X-Delivery", "delivery-1")
+			delivery, duplicate, err := webhookDeliveryService.RecordDelivery(r, []byte("{}"), true)
+			if err != nil {
+				t.Fatalf("RecordDelivery: %v", err)
+			}
+			if duplicate != tt.wantDuplicate {
+				t.Fatalf("duplicate = %v, want %v", duplicate, tt.wantDuplicate)
+			}
+			if !duplicate && (delivery.Status != webhookDelivery.StatusReceived || delivery.Attempts != 0) {
+				t.Errorf("redelivery stored with status %s and %d attempts, want received with 0 attempts", delivery.Status, delivery.Attempts)
+			}
+		})
+	}
+}
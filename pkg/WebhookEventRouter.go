@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"errors"
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/pkg/webhookDelivery"
+	"go.uber.org/zap"
+	"net/http"
+	"sync"
+)
+
+const EventTypePing = "ping"
+
+var ErrWebhookEventTypeMissing = errors.New("webhook event type header missing")
+
+// WebhookEventResponse is written back to git provider, Result is omitted for 204
+type WebhookEventResponse struct {
+	Status int
+	Result interface{}
+}
+
+// WebhookEventHandler handles deliveries of one event type, handlers are registered on WebhookEventRouter
+type WebhookEventHandler interface {
+	EventType() string
+	Handle(delivery *webhookDelivery.WebhookDelivery) (*WebhookEventResponse, error)
+}
+
+type WebhookEventRouter interface {
+	// RegisterHandler adds handler for its event type, replaces any handler registered earlier for same type
+	RegisterHandler(handler WebhookEventHandler)
+	// Route dispatches a recorded delivery to the handler of its event type. Ping is always handled,
+	// events without a handler or not in subscribed events are acknowledged with 204.
+	Route(delivery *webhookDelivery.WebhookDelivery) (*WebhookEventResponse, error)
+	SubscribedEvents() []string
+}
+
+type WebhookEventRouterImpl struct {
+	logger                 *zap.SugaredLogger
+	client                 *util.GitHubClient
+	webhookDeliveryService WebhookDeliveryService
+	lock                   sync.RWMutex
+	handlers               map[string]WebhookEventHandler
+}
+
+func NewWebhookEventRouterImpl(logger *zap.SugaredLogger, client *util.GitHubClient, webhookDeliveryService WebhookDeliveryService,
+	webhookQueueService WebhookQueueService) *WebhookEventRouterImpl {
+	impl := &WebhookEventRouterImpl{
+		logger:                 logger,
+		client:                 client,
+		webhookDeliveryService: webhookDeliveryService,
+		handlers:               make(map[string]WebhookEventHandler),
+	}
+	impl.RegisterHandler(NewPingEventHandler(impl, webhookDeliveryService))
+	impl.RegisterHandler(NewReleaseEventHandler(webhookQueueService))
+	return impl
+}
+
+func (impl *WebhookEventRouterImpl) RegisterHandler(handler WebhookEventHandler) {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+	impl.handlers[handler.EventType()] = handler
+}
+
+func (impl *WebhookEventRouterImpl) Route(delivery *webhookDelivery.WebhookDelivery) (*WebhookEventResponse, error) {
+	eventType := delivery.EventType
+	if len(eventType) == 0 {
+		impl.webhookDeliveryService.CompleteDelivery(delivery, false, ErrWebhookEventTypeMissing)
+		return nil, ErrWebhookEventTypeMissing
+	}
+	handler := impl.getHandler(eventType)
+	if handler == nil || (eventType != EventTypePing && !impl.isSubscribed(eventType)) {
+		impl.logger.Infow("acknowledged unsubscribed webhook event", "eventType", eventType, "deliveryId", delivery.DeliveryId)
+		impl.webhookDeliveryService.CompleteDelivery(delivery, false, nil)
+		return &WebhookEventResponse{Status: http.StatusNoContent}, nil
+	}
+	impl.logger.Debugw("routing webhook event", "eventType", eventType, "deliveryId", delivery.DeliveryId)
+	return handler.Handle(delivery)
+}
+
+// SubscribedEvents returns configured events which have a registered handler
+func (impl *WebhookEventRouterImpl) SubscribedEvents() []string {
+	subscribedEvents := make([]string, 0)
+	for _, eventType := range impl.client.GitHubConfig.GitHubSubscribedEvents {
+		if impl.getHandler(eventType) != nil {
+			subscribedEvents = append(subscribedEvents, eventType)
+		}
+	}
+	return subscribedEvents
+}
+
+func (impl *WebhookEventRouterImpl) getHandler(eventType string) WebhookEventHandler {
+	impl.lock.RLock()
+	defer impl.lock.RUnlock()
+	return impl.handlers[eventType]
+}
+
+func (impl *WebhookEventRouterImpl) isSubscribed(eventType string) bool {
+	for _, subscribedEvent := range impl.client.GitHubConfig.GitHubSubscribedEvents {
+		if subscribedEvent == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// ReleaseEventHandler queues release events, releases are updated by webhook workers
+type ReleaseEventHandler struct {
+	webhookQueueService WebhookQueueService
+}
+
+func NewReleaseEventHandler(webhookQueueService WebhookQueueService) *ReleaseEventHandler {
+	return &ReleaseEventHandler{webhookQueueService: webhookQueueService}
+}
+
+func (handler *ReleaseEventHandler) EventType() string {
+	return EventTypeRelease
+}
+
+func (handler *ReleaseEventHandler) Handle(delivery *webhookDelivery.WebhookDelivery) (*WebhookEventResponse, error) {
+	err := handler.webhookQueueService.Enqueue(delivery)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookEventResponse{Status: http.StatusAccepted, Result: delivery.DeliveryId}, nil
+}
+
+// PingEventHandler replies to ping sent by github on webhook creation with hook id and subscribed events
+type PingEventHandler struct {
+	webhookEventRouter     WebhookEventRouter
+	webhookDeliveryService WebhookDeliveryService
+}
+
+func NewPingEventHandler(webhookEventRouter WebhookEventRouter, webhookDeliveryService WebhookDeliveryService) *PingEventHandler {
+	return &PingEventHandler{
+		webhookEventRouter:     webhookEventRouter,
+		webhookDeliveryService: webhookDeliveryService,
+	}
+}
+
+func (handler *PingEventHandler) EventType() string {
+	return EventTypePing
+}
+
+func (handler *PingEventHandler) Handle(delivery *webhookDelivery.WebhookDelivery) (*WebhookEventResponse, error) {
+	pingResponse, err := DecodePingEvent([]byte(delivery.Body))
+	if err != nil {
+		handler.webhookDeliveryService.CompleteDelivery(delivery, false, err)
+		return nil, err
+	}
+	pingResponse.SubscribedEvents = handler.webhookEventRouter.SubscribedEvents()
+	handler.webhookDeliveryService.CompleteDelivery(delivery, false, nil)
+	return &WebhookEventResponse{Status: http.StatusOK, Result: pingResponse}, nil
+}
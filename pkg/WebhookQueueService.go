@@ -0,0 +1,232 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"context"
+	"errors"
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/internal/metrics"
+	"github.com/devtron-labs/central-api/pkg/webhookDelivery"
+	"go.uber.org/zap"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrWebhookQueueFull = errors.New("webhook queue is full")
+var ErrWebhookQueueStopped = errors.New("webhook queue is stopped")
+
+var webhookDeliveryOutcomes = metrics.NewCounterVec("central_api_webhook_deliveries_total",
+	"webhook deliveries applied by workers by outcome", "status")
+var webhookDeliveryRetries = metrics.NewCounterVec("central_api_webhook_delivery_retries_total",
+	"failed attempts of queued webhook deliveries which were retried")
+
+type WebhookQueueService interface {
+	// Enqueue validates payload of a recorded delivery and queues it, deliveries are applied by worker pool
+	// through UpdateReleases with exponential backoff and moved to dead letter after max attempts. Deliveries of
+	// the same repo and tag always go to the same worker, so they are applied in the order they were queued.
+	Enqueue(delivery *webhookDelivery.WebhookDelivery) error
+	// Stop stops accepting deliveries and waits till queued ones are applied or ctx is done
+	Stop(ctx context.Context) error
+}
+
+type WebhookQueueServiceImpl struct {
+	logger                 *zap.SugaredLogger
+	config                 *util.WebhookDeliveryConfig
+	releaseNoteService     ReleaseNoteService
+	webhookDeliveryService WebhookDeliveryService
+	releaseSource          ReleaseSource
+	// queues has a queue per worker, see getQueue
+	queues []chan *webhookDelivery.WebhookDelivery
+	// stopping is closed when drain times out, backoff waits are cut short and remaining deliveries are left queued
+	stopping     chan struct{}
+	stopOnce     sync.Once
+	stoppingOnce sync.Once
+	lock         sync.RWMutex
+	stopped      bool
+	waitGroup    sync.WaitGroup
+}
+
+func NewWebhookQueueServiceImpl(logger *zap.SugaredLogger, config *util.WebhookDeliveryConfig, releaseNoteService ReleaseNoteService,
+	webhookDeliveryService WebhookDeliveryService, releaseSource ReleaseSource) *WebhookQueueServiceImpl {
+	impl := &WebhookQueueServiceImpl{
+		logger:                 logger,
+		config:                 config,
+		releaseNoteService:     releaseNoteService,
+		webhookDeliveryService: webhookDeliveryService,
+		releaseSource:          releaseSource,
+		stopping:               make(chan struct{}),
+	}
+	workerCount := config.WorkerCount
+	if workerCount <= 0 {
+		workerCount = 1
+	}
+	// queue size is split between workers, rounded up so that every worker can hold at least one delivery
+	queueSize := (config.QueueSize + workerCount - 1) / workerCount
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	for i := 0; i < workerCount; i++ {
+		queue := make(chan *webhookDelivery.WebhookDelivery, queueSize)
+		impl.queues = append(impl.queues, queue)
+		impl.waitGroup.Add(1)
+		go impl.work(queue)
+	}
+	go impl.requeuePendingDeliveries()
+	return impl
+}
+
+func (impl *WebhookQueueServiceImpl) Enqueue(delivery *webhookDelivery.WebhookDelivery) error {
+	if _, err := impl.releaseSource.DecodeReleaseEvent([]byte(delivery.Body)); err != nil {
+		impl.webhookDeliveryService.CompleteDelivery(delivery, false, err)
+		return err
+	}
+	err := impl.webhookDeliveryService.QueueDelivery(delivery)
+	if err != nil {
+		return err
+	}
+	return impl.push(delivery)
+}
+
+func (impl *WebhookQueueServiceImpl) push(delivery *webhookDelivery.WebhookDelivery) error {
+	impl.lock.RLock()
+	defer impl.lock.RUnlock()
+	if impl.stopped {
+		// delivery stays queued in delivery log and is picked again on startup
+		return ErrWebhookQueueStopped
+	}
+	select {
+	case impl.getQueue(delivery) <- delivery:
+		return nil
+	default:
+		impl.webhookDeliveryService.CompleteDelivery(delivery, false, ErrWebhookQueueFull)
+		return ErrWebhookQueueFull
+	}
+}
+
+// requeuePendingDeliveries queues deliveries left in queued state by a previous shutdown.
+// UpdateReleases is idempotent so a delivery picked by more than one replica is harmless.
+func (impl *WebhookQueueServiceImpl) requeuePendingDeliveries() {
+	deliveries, err := impl.webhookDeliveryService.GetQueuedDeliveries(impl.config.QueueSize)
+	if err != nil {
+		impl.logger.Errorw("error in fetching queued webhook deliveries", "err", err)
+		return
+	}
+	// oldest first so that events are applied in the order they were received
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		impl.logger.Infow("requeue pending webhook delivery", "deliveryId", deliveries[i].DeliveryId)
+		if err := impl.push(deliveries[i]); err != nil {
+			impl.logger.Errorw("error in requeue of webhook delivery", "deliveryId", deliveries[i].DeliveryId, "err", err)
+		}
+	}
+}
+
+// getQueue picks queue of a worker by repo and tag of delivery, a worker applies its deliveries one at a time
+// and retries in place so published, edited and deleted events of a release are never reordered
+func (impl *WebhookQueueServiceImpl) getQueue(delivery *webhookDelivery.WebhookDelivery) chan *webhookDelivery.WebhookDelivery {
+	key := ""
+	releaseEvent, err := impl.releaseSource.DecodeReleaseEvent([]byte(delivery.Body))
+	if err == nil {
+		key = strings.ToLower(releaseEvent.Repo) + "@" + releaseEvent.Release.TagName
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return impl.queues[hash.Sum32()%uint32(len(impl.queues))]
+}
+
+func (impl *WebhookQueueServiceImpl) work(queue chan *webhookDelivery.WebhookDelivery) {
+	defer impl.waitGroup.Done()
+	for delivery := range queue {
+		select {
+		case <-impl.stopping:
+			return
+		default:
+		}
+		impl.process(delivery)
+	}
+}
+
+func (impl *WebhookQueueServiceImpl) process(delivery *webhookDelivery.WebhookDelivery) {
+	backoff := impl.config.RetryInitialBackoff
+	for {
+		delivery.Attempts++
+		updated, err := impl.releaseNoteService.UpdateReleases([]byte(delivery.Body))
+		if err == nil {
+			impl.logger.Infow("webhook delivery applied", "deliveryId", delivery.DeliveryId, "attempts", delivery.Attempts, "updated", updated)
+			impl.webhookDeliveryService.CompleteDelivery(delivery, updated, nil)
+			webhookDeliveryOutcomes.Inc(delivery.Status)
+			return
+		}
+		if _, ok := err.(*InvalidPayloadError); ok {
+			impl.webhookDeliveryService.CompleteDelivery(delivery, false, err)
+			webhookDeliveryOutcomes.Inc(delivery.Status)
+			return
+		}
+		if delivery.Attempts >= impl.config.MaxAttempts {
+			impl.logger.Errorw("webhook delivery moved to dead letter", "deliveryId", delivery.DeliveryId, "attempts", delivery.Attempts, "err", err)
+			impl.webhookDeliveryService.DeadLetterDelivery(delivery, err)
+			webhookDeliveryOutcomes.Inc(delivery.Status)
+			return
+		}
+		impl.logger.Warnw("error in applying webhook delivery, retrying", "deliveryId", delivery.DeliveryId, "attempts", delivery.Attempts, "backoff", backoff, "err", err)
+		webhookDeliveryRetries.Inc()
+		select {
+		case <-time.After(backoff):
+		case <-impl.stopping:
+			// shutting down, delivery stays queued and is picked again on startup
+			impl.logger.Infow("webhook queue stopping, retry left for next startup", "deliveryId", delivery.DeliveryId)
+			return
+		}
+		backoff *= 2
+		if backoff > impl.config.RetryMaxBackoff {
+			backoff = impl.config.RetryMaxBackoff
+		}
+	}
+}
+
+func (impl *WebhookQueueServiceImpl) Stop(ctx context.Context) error {
+	impl.stopOnce.Do(func() {
+		impl.lock.Lock()
+		impl.stopped = true
+		for _, queue := range impl.queues {
+			close(queue)
+		}
+		impl.lock.Unlock()
+	})
+	drained := make(chan struct{})
+	go func() {
+		impl.waitGroup.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		impl.logger.Infow("webhook queue drained")
+		return nil
+	case <-ctx.Done():
+		impl.stoppingOnce.Do(func() {
+			close(impl.stopping)
+		})
+		pending := 0
+		for _, queue := range impl.queues {
+			pending += len(queue)
+		}
+		impl.logger.Warnw("webhook queue drain timed out, pending deliveries are picked on next startup", "pending", pending)
+		return ctx.Err()
+	}
+}
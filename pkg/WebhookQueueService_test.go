@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/common"
+	"github.com/devtron-labs/central-api/pkg/webhookDelivery"
+	"go.uber.org/zap"
+)
+
+// actionReleaseSource decodes payloads of form "<action> <tag>"
+type actionReleaseSource struct {
+	ReleaseSource
+}
+
+func (impl *actionReleaseSource) DecodeReleaseEvent(requestBodyBytes []byte) (*ReleaseEvent, error) {
+	fields := strings.Fields(string(requestBodyBytes))
+	if len(fields) != 2 {
+		return nil, &InvalidPayloadError{}
+	}
+	return &ReleaseEvent{Action: fields[0], Repo: conformanceRepo, Release: &common.Release{TagName: fields[1]}}, nil
+}
+
+// orderRecordingReleaseNoteService applies events to a set of tags and records actions applied per tag,
+// publishing is slow so that a delete queued after it would overtake it on another worker
+type orderRecordingReleaseNoteService struct {
+	ReleaseNoteService
+	releaseSource ReleaseSource
+	mutex         sync.Mutex
+	actions       map[string][]string
+	tags          map[string]bool
+}
+
+func (impl *orderRecordingReleaseNoteService) UpdateReleases(requestBodyBytes []byte) (bool, error) {
+	releaseEvent, err := impl.releaseSource.DecodeReleaseEvent(requestBodyBytes)
+	if err != nil {
+		return false, err
+	}
+	if releaseEvent.Action == ActionPublished {
+		time.Sleep(5 * time.Millisecond)
+	}
+	impl.mutex.Lock()
+	defer impl.mutex.Unlock()
+	tagName := releaseEvent.Release.TagName
+	impl.actions[tagName] = append(impl.actions[tagName], releaseEvent.Action)
+	impl.tags[tagName] = releaseEvent.Action != ActionDeleted
+	return true, nil
+}
+
+type noopWebhookDeliveryService struct {
+	WebhookDeliveryService
+}
+
+func (impl *noopWebhookDeliveryService) QueueDelivery(delivery *webhookDelivery.WebhookDelivery) error {
+	return nil
+}
+
+func (impl *noopWebhookDeliveryService) CompleteDelivery(delivery *webhookDelivery.WebhookDelivery, updated bool, processingErr error) {
+}
+
+func (impl *noopWebhookDeliveryService) DeadLetterDelivery(delivery *webhookDelivery.WebhookDelivery, processingErr error) {
+}
+
+func (impl *noopWebhookDeliveryService) GetQueuedDeliveries(size int) ([]*webhookDelivery.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func TestWebhookQueueKeepsOrderOfReleaseEvents(t *testing.T) {
+	releaseSource := &actionReleaseSource{}
+	releaseNoteService := &orderRecordingReleaseNoteService{
+		releaseSource: releaseSource,
+		actions:       map[string][]string{},
+		tags:          map[string]bool{},
+	}
+	config := &util.WebhookDeliveryConfig{WorkerCount: 4, QueueSize: 400, MaxAttempts: 1}
+	webhookQueueService := NewWebhookQueueServiceImpl(zap.NewNop().Sugar(), config, releaseNoteService, &noopWebhookDeliveryService{}, releaseSource)
+
+	const tags = 20
+	for i := 0; i < tags; i++ {
+		for _, action := range []string{ActionPublished, ActionDeleted} {
+			delivery := &webhookDelivery.WebhookDelivery{DeliveryId: fmt.Sprintf("%s-%d", action, i), Body: fmt.Sprintf("%s v1.%d.0", action, i)}
+			if err := webhookQueueService.Enqueue(delivery); err != nil {
+				t.Fatalf("Enqueue %s: %v", delivery.DeliveryId, err)
+			}
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := webhookQueueService.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	for i := 0; i < tags; i++ {
+		tagName := fmt.Sprintf("v1.%d.0", i)
+		if want := []string{ActionPublished, ActionDeleted}; !reflect.DeepEqual(releaseNoteService.actions[tagName], want) {
+			t.Errorf("actions applied on %s = %v, want %v", tagName, releaseNoteService.actions[tagName], want)
+		}
+		if releaseNoteService.tags[tagName] {
+			t.Errorf("deleted release %s is still visible", tagName)
+		}
+	}
+}
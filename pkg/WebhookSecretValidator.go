@@ -19,11 +19,17 @@ package pkg
 import (
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/internal/metrics"
+	"github.com/gorilla/mux"
 	"go.uber.org/zap"
+	"hash"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type WebhookSecretValidator interface {
@@ -44,45 +50,124 @@ func NewWebhookSecretValidatorImpl(Logger *zap.SugaredLogger, client *util.GitHu
 
 const (
 	SECRET_VALIDATOR_SHA1       string = "SHA-1"
+	SECRET_VALIDATOR_SHA256     string = "SHA-256"
+	SECRET_VALIDATOR_AUTO       string = "auto"
 	SECRET_VALIDATOR_URL_APPEND string = "URL_APPEND"
 	SECRET_VALIDATOR_PLAIN_TEXT string = "PLAIN_TEXT"
 )
 
-// Validate secret for some predefined algorithms : SHA1, URL_APPEND, PLAIN_TEXT
+// WebhookSecretPathVariable is the path variable holding secret in /release/webhook/{secret}
+const WebhookSecretPathVariable = "secret"
+
+const (
+	SIGNATURE_PREFIX_SHA1   = "sha1"
+	SIGNATURE_PREFIX_SHA256 = "sha256"
+)
+
+var deprecatedWebhookSecretDeliveries = metrics.NewCounterVec("central_api_webhook_deprecated_secret_deliveries_total",
+	"webhook deliveries validated with a secret other than the current one", "keyId")
+
+// Validate secret for some predefined algorithms : SHA1, SHA256, AUTO, URL_APPEND, PLAIN_TEXT
 // URL_APPEND : Secret will come in URL (last path param of URL)
 // PLAIN_TEXT : Plain text value in request header
-// SHA1 : SHA1 encrypted text in request header
+// SHA1 : SHA1 HMAC of request body in request header
+// SHA256 : SHA256 HMAC of request body in X-Hub-Signature-256 header
+// AUTO : SHA256 when its header is present, SHA1 otherwise
+// Every non expired configured secret is tried, current secret first, to allow rotation without downtime
 func (impl *WebhookSecretValidatorImpl) ValidateSecret(r *http.Request, requestBodyBytes []byte) bool {
 
 	secretValidator := impl.client.GitHubConfig.GitHubSecretValidator
 	impl.logger.Debug("Validating signature for secret validator : ", secretValidator)
 
+	var validate func(secret string) bool
 	switch secretValidator {
 
 	case SECRET_VALIDATOR_SHA1:
+		validate = impl.getSha1Validator(r, requestBodyBytes)
 
-		gotHash := strings.SplitN(r.Header.Get(impl.client.GitHubConfig.GitHubSecretHeader), "=", 2)
-		if gotHash[0] != "sha1" {
-			return false
-		}
-		hash := hmac.New(sha1.New, []byte(impl.client.GitHubConfig.GitHubWebhookSecret))
-		if _, err := hash.Write(requestBodyBytes); err != nil {
+	case SECRET_VALIDATOR_SHA256:
+		validate = impl.getSha256Validator(r, requestBodyBytes)
+
+	case SECRET_VALIDATOR_AUTO:
+		if len(r.Header.Get(impl.client.GitHubConfig.GitHubSecret256Header)) > 0 {
+			validate = impl.getSha256Validator(r, requestBodyBytes)
+		} else if len(r.Header.Get(impl.client.GitHubConfig.GitHubSecretHeader)) > 0 {
+			impl.logger.Debugw("sha256 signature header not found, falling back to sha1")
+			validate = impl.getSha1Validator(r, requestBodyBytes)
+		} else {
+			impl.logger.Errorw("no signature header found in request")
 			return false
 		}
-		expectedHash := hex.EncodeToString(hash.Sum(nil))
-		return gotHash[1] == expectedHash
 
 	case SECRET_VALIDATOR_URL_APPEND:
-		//secretFromUrlFromDb := gitHost.WebhookUrl[strings.LastIndex(gitHost.WebhookUrl, "/")+1:]
-		///return secretInUrl == secretFromUrlFromDb
+		secretFromUrl := mux.Vars(r)[WebhookSecretPathVariable]
+		validate = func(secret string) bool {
+			return len(secretFromUrl) > 0 && subtle.ConstantTimeCompare([]byte(secretFromUrl), []byte(secret)) == 1
+		}
 
 	case SECRET_VALIDATOR_PLAIN_TEXT:
 		secretHeaderValue := r.Header.Get(impl.client.GitHubConfig.GitHubSecretHeader)
-		return secretHeaderValue == impl.client.GitHubConfig.GitHubWebhookSecret
+		validate = func(secret string) bool {
+			return subtle.ConstantTimeCompare([]byte(secretHeaderValue), []byte(secret)) == 1
+		}
 
 	default:
 		impl.logger.Errorw("unsupported SecretValidator ", "SecretValidator", secretValidator)
+		return false
 	}
 
+	return impl.matchAnySecret(validate)
+}
+
+// matchAnySecret tries every non expired secret and logs key id of the matched one,
+// deliveries matched with a previous secret are counted so that rotation progress is visible
+func (impl *WebhookSecretValidatorImpl) matchAnySecret(validate func(secret string) bool) bool {
+	now := time.Now()
+	for i, webhookSecret := range impl.client.WebhookSecrets {
+		if len(webhookSecret.Secret) == 0 || webhookSecret.IsExpired(now) {
+			continue
+		}
+		if !validate(webhookSecret.Secret) {
+			continue
+		}
+		if i > 0 {
+			impl.logger.Warnw("webhook validated with deprecated secret", "keyId", webhookSecret.KeyId)
+			deprecatedWebhookSecretDeliveries.Inc(webhookSecret.KeyId)
+		} else {
+			impl.logger.Infow("webhook validated", "keyId", webhookSecret.KeyId)
+		}
+		return true
+	}
 	return false
 }
+
+func (impl *WebhookSecretValidatorImpl) getSha1Validator(r *http.Request, requestBodyBytes []byte) func(secret string) bool {
+	signature := r.Header.Get(impl.client.GitHubConfig.GitHubSecretHeader)
+	return func(secret string) bool {
+		return validateHmacSignature(signature, SIGNATURE_PREFIX_SHA1, sha1.New, secret, requestBodyBytes)
+	}
+}
+
+func (impl *WebhookSecretValidatorImpl) getSha256Validator(r *http.Request, requestBodyBytes []byte) func(secret string) bool {
+	signature := r.Header.Get(impl.client.GitHubConfig.GitHubSecret256Header)
+	return func(secret string) bool {
+		return validateHmacSignature(signature, SIGNATURE_PREFIX_SHA256, sha256.New, secret, requestBodyBytes)
+	}
+}
+
+// validateHmacSignature checks signature of format <prefix>=<hex hmac of body> in constant time
+func validateHmacSignature(signature string, prefix string, hashFunc func() hash.Hash, secret string, requestBodyBytes []byte) bool {
+	gotHash := strings.SplitN(signature, "=", 2)
+	if len(gotHash) != 2 || gotHash[0] != prefix {
+		return false
+	}
+	gotHashBytes, err := hex.DecodeString(gotHash[1])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(hashFunc, []byte(secret))
+	if _, err := mac.Write(requestBodyBytes); err != nil {
+		return false
+	}
+	return hmac.Equal(gotHashBytes, mac.Sum(nil))
+}
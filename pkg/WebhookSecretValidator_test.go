@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// getDeprecatedSecretDeliveries reads count of deliveries validated with deprecated secret keyId from metrics endpoint
+func getDeprecatedSecretDeliveries(t *testing.T, keyId string) float64 {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	sampleRegex := regexp.MustCompile(fmt.Sprintf(`(?m)^central_api_webhook_deprecated_secret_deliveries_total\{keyId=%q\} (\S+)$`, keyId))
+	match := sampleRegex.FindStringSubmatch(recorder.Body.String())
+	if match == nil {
+		return 0
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		t.Fatalf("invalid metric value %q: %v", match[1], err)
+	}
+	return value
+}
+
+func TestValidateSecretLegacySecret(t *testing.T) {
+	tests := []struct {
+		name           string
+		webhookSecrets string
+		secret         string
+		wantValid      bool
+		wantDeprecated bool
+	}{
+		{name: "legacy secret only", secret: "legacy", wantValid: true},
+		{name: "legacy secret with empty rotation list", webhookSecrets: "[]", secret: "legacy", wantValid: true},
+		{name: "legacy secret after rotation list", webhookSecrets: `[{"id":"new","secret":"new"}]`, secret: "legacy", wantValid: true, wantDeprecated: true},
+		{name: "current secret of rotation list", webhookSecrets: `[{"id":"new","secret":"new"}]`, secret: "new", wantValid: true},
+		{name: "unknown secret", secret: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitHubConfig := &util.GitHubConfig{
+				GitHubWebhookSecret:   "legacy",
+				GitHubWebhookSecrets:  tt.webhookSecrets,
+				GitHubSecretHeader:    "X-Hub-Signature",
+				GitHubSecretValidator: SECRET_VALIDATOR_PLAIN_TEXT,
+			}
+			webhookSecrets, err := gitHubConfig.GetWebhookSecrets()
+			if err != nil {
+				t.Fatalf("GetWebhookSecrets: %v", err)
+			}
+			webhookSecretValidator := NewWebhookSecretValidatorImpl(zap.NewNop().Sugar(), &util.GitHubClient{GitHubConfig: gitHubConfig, WebhookSecrets: webhookSecrets})
+			request := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+			request.Header.Set("X-Hub-Signature", tt.secret)
+
+			before := getDeprecatedSecretDeliveries(t, util.DefaultWebhookSecretKeyId)
+			if valid := webhookSecretValidator.ValidateSecret(request, nil); valid != tt.wantValid {
+				t.Fatalf("ValidateSecret = %v, want %v", valid, tt.wantValid)
+			}
+			deprecated := getDeprecatedSecretDeliveries(t, util.DefaultWebhookSecretKeyId) > before
+			if deprecated != tt.wantDeprecated {
+				t.Fatalf("delivery counted as deprecated = %v, want %v", deprecated, tt.wantDeprecated)
+			}
+		})
+	}
+}
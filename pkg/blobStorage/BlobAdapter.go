@@ -0,0 +1,173 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blobStorage
+
+import (
+	"cloud.google.com/go/storage"
+	"errors"
+	"fmt"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	util "github.com/devtron-labs/central-api/client"
+	blob_storage "github.com/devtron-labs/common-lib/blob-storage"
+	"go.uber.org/zap"
+	"os"
+)
+
+// ErrBlobNotFound is wrapped by errors of Get when key does not exist, check it with errors.Is
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobAdapter puts and gets byte content in configured bucket. blob_storage works on local files only, so every
+// call goes through its own temp file which is removed before returning, concurrent calls never share a path.
+type BlobAdapter interface {
+	Put(key string, content []byte) error
+	// Get returns error wrapping ErrBlobNotFound if key does not exist
+	Get(key string) ([]byte, error)
+}
+
+// NewBlobAdapter returns filesystem adapter for filesystem storage backend and bucket adapter otherwise
+func NewBlobAdapter(logger *zap.SugaredLogger, storageConfig *util.StorageConfig, blobConfig *util.BlobConfigVariables,
+	blobStorageService *blob_storage.BlobStorageServiceImpl) (BlobAdapter, error) {
+	if storageConfig.Backend == util.STORAGE_BACKEND_FILESYSTEM {
+		fileSystemBlobAdapter, err := NewFileSystemBlobAdapterImpl(logger, storageConfig.FileSystemDir)
+		if err != nil {
+			return nil, err
+		}
+		return fileSystemBlobAdapter, nil
+	}
+	return NewBlobAdapterImpl(logger, blobConfig, blobStorageService), nil
+}
+
+type BlobAdapterImpl struct {
+	logger             *zap.SugaredLogger
+	blobConfig         *util.BlobConfigVariables
+	blobStorageService *blob_storage.BlobStorageServiceImpl
+}
+
+func NewBlobAdapterImpl(logger *zap.SugaredLogger, blobConfig *util.BlobConfigVariables, blobStorageService *blob_storage.BlobStorageServiceImpl) *BlobAdapterImpl {
+	return &BlobAdapterImpl{
+		logger:             logger,
+		blobConfig:         blobConfig,
+		blobStorageService: blobStorageService,
+	}
+}
+
+func (impl *BlobAdapterImpl) Put(key string, content []byte) error {
+	file, err := os.CreateTemp("", "blob-put-*")
+	if err != nil {
+		impl.logger.Errorw("error in creating temp file for blob upload", "key", key, "err", err)
+		return err
+	}
+	defer impl.removeFile(file.Name())
+	_, err = file.Write(content)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		impl.logger.Errorw("error in writing temp file for blob upload", "key", key, "err", err)
+		return err
+	}
+	request := impl.createBlobStorageRequest(file.Name(), key)
+	err = impl.blobStorageService.UploadToBlobWithSession(request)
+	if err != nil {
+		impl.logger.Errorw("error in uploading to blob", "key", key, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (impl *BlobAdapterImpl) Get(key string) ([]byte, error) {
+	file, err := os.CreateTemp("", "blob-get-*")
+	if err != nil {
+		impl.logger.Errorw("error in creating temp file for blob download", "key", key, "err", err)
+		return nil, err
+	}
+	file.Close()
+	defer impl.removeFile(file.Name())
+	// blob_storage prefixes destination with "/", temp file path is already absolute so the result is same file
+	request := impl.createBlobStorageRequest(key, file.Name())
+	downloaded, _, err := impl.blobStorageService.Get(request)
+	if isBlobNotFoundError(err) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in downloading from blob", "key", key, "err", err)
+		return nil, err
+	}
+	if !downloaded {
+		// s3 download of blob_storage reports every failure, missing key included, as not downloaded without error
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+	}
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		impl.logger.Errorw("error in reading file downloaded from blob", "key", key, "err", err)
+		return nil, err
+	}
+	return content, nil
+}
+
+// isBlobNotFoundError checks missing object errors of azure and gcp, s3 errors are not returned by blob_storage
+func isBlobNotFoundError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return true
+	}
+	var storageError azblob.StorageError
+	return errors.As(err, &storageError) && storageError.ServiceCode() == azblob.ServiceCodeBlobNotFound
+}
+
+func (impl *BlobAdapterImpl) removeFile(name string) {
+	err := os.Remove(name)
+	if err != nil && !os.IsNotExist(err) {
+		impl.logger.Warnw("error in removing temp blob file", "file", name, "err", err)
+	}
+}
+
+func (impl *BlobAdapterImpl) createBlobStorageRequest(sourceKey string, destinationKey string) *blob_storage.BlobStorageRequest {
+	request := &blob_storage.BlobStorageRequest{
+		StorageType:    impl.blobConfig.BlobStorageType,
+		SourceKey:      sourceKey,
+		DestinationKey: destinationKey,
+	}
+	switch impl.blobConfig.BlobStorageType {
+	case blob_storage.BLOB_STORAGE_S3:
+		request.AwsS3BaseConfig = &blob_storage.AwsS3BaseConfig{
+			AccessKey:         impl.blobConfig.S3AccessKey,
+			Passkey:           impl.blobConfig.S3Passkey,
+			EndpointUrl:       impl.blobConfig.S3EndpointUrl,
+			IsInSecure:        impl.blobConfig.S3IsInSecure,
+			BucketName:        impl.blobConfig.S3BucketName,
+			Region:            impl.blobConfig.S3Region,
+			VersioningEnabled: impl.blobConfig.S3VersioningEnabled,
+		}
+	case blob_storage.BLOB_STORAGE_AZURE:
+		request.AzureBlobBaseConfig = &blob_storage.AzureBlobBaseConfig{
+			AccountKey:        impl.blobConfig.AzureAccountKey,
+			AccountName:       impl.blobConfig.AzureAccountName,
+			Enabled:           impl.blobConfig.AzureEnabled,
+			BlobContainerName: impl.blobConfig.AzureBlobContainerName,
+		}
+	case blob_storage.BLOB_STORAGE_GCP:
+		request.GcpBlobBaseConfig = &blob_storage.GcpBlobBaseConfig{
+			CredentialFileJsonData: impl.blobConfig.GcpCredentialFileJsonData,
+			BucketName:             impl.blobConfig.GcpBucketName,
+		}
+	}
+	return request
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blobStorage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	util "github.com/devtron-labs/central-api/client"
+	blob_storage "github.com/devtron-labs/common-lib/blob-storage"
+	"go.uber.org/zap"
+)
+
+const testBucketName = "central-api-test"
+
+// fakeS3Server keeps objects of a single bucket in memory, it serves path style PutObject and GetObject
+// which is all blob_storage uses for upload with session and download
+type fakeS3Server struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/"+testBucketName+"/")
+	if key == r.URL.Path || len(key) == 0 {
+		http.Error(w, "unknown bucket", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		content, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.mutex.Lock()
+		s.objects[key] = content
+		s.mutex.Unlock()
+		w.Header().Set("ETag", strconv.Quote(strconv.Itoa(len(content))))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		s.mutex.Lock()
+		content, ok := s.objects[key]
+		s.mutex.Unlock()
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Key>%s</Key></Error>`, key)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(content)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// newTestBlobAdapter runs against S3_END_POINT_URL and S3_BUCKET_NAME when they are set, e.g. a MinIO
+// endpoint, and against an in memory S3 stand-in otherwise
+func newTestBlobAdapter(t *testing.T) *BlobAdapterImpl {
+	logger := zap.NewNop().Sugar()
+	// temp files of the adapter are created here so that leftovers can be detected
+	t.Setenv("TMPDIR", t.TempDir())
+	blobConfig := &util.BlobConfigVariables{}
+	if len(os.Getenv("S3_END_POINT_URL")) > 0 && len(os.Getenv("S3_BUCKET_NAME")) > 0 {
+		var err error
+		blobConfig, err = util.NewBlobConfig(logger)
+		if err != nil {
+			t.Fatalf("error in parsing blob config: %v", err)
+		}
+		blobConfig.BlobStorageType = blob_storage.BLOB_STORAGE_S3
+	} else {
+		// aws sdk writes custom CA bundle into shared default http client on every session, stand-in is plain http
+		t.Setenv("AWS_CA_BUNDLE", "")
+		server := httptest.NewServer(&fakeS3Server{objects: map[string][]byte{}})
+		t.Cleanup(server.Close)
+		blobConfig.BlobStorageType = blob_storage.BLOB_STORAGE_S3
+		blobConfig.S3EndpointUrl = server.URL
+		blobConfig.S3IsInSecure = true
+		blobConfig.S3AccessKey = "access-key"
+		blobConfig.S3Passkey = "pass-key"
+		blobConfig.S3BucketName = testBucketName
+		blobConfig.S3Region = "us-east-1"
+	}
+	return NewBlobAdapterImpl(logger, blobConfig, blob_storage.NewBlobStorageServiceImpl(logger))
+}
+
+// uniqueKey keeps keys of separate runs apart when a shared bucket is used
+func uniqueKey(name string) string {
+	return fmt.Sprintf("blob-adapter-test-%d-%s", time.Now().UnixNano(), name)
+}
+
+func assertNoTempFiles(t *testing.T) {
+	t.Helper()
+	entries, err := os.ReadDir(os.TempDir())
+	if err != nil {
+		t.Fatalf("error in reading temp dir: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "blob-put-") || strings.HasPrefix(entry.Name(), "blob-get-") {
+			t.Errorf("temp file %s is not removed", entry.Name())
+		}
+	}
+}
+
+func TestBlobAdapterPutGet(t *testing.T) {
+	blobAdapter := newTestBlobAdapter(t)
+	key := uniqueKey("latest.txt")
+	tests := [][]byte{
+		[]byte("v0.7.0"),
+		[]byte("v0.7.1-rc.1"),
+		{},
+	}
+	for _, content := range tests {
+		err := blobAdapter.Put(key, content)
+		if err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		got, err := blobAdapter.Get(key)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Fatalf("Get = %q, want %q", got, content)
+		}
+	}
+	assertNoTempFiles(t)
+}
+
+func TestBlobAdapterGetMissingKey(t *testing.T) {
+	blobAdapter := newTestBlobAdapter(t)
+	content, err := blobAdapter.Get(uniqueKey("missing.txt"))
+	if !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("Get of missing key = %q, %v, want ErrBlobNotFound", content, err)
+	}
+	assertNoTempFiles(t)
+}
+
+func TestBlobAdapterConcurrentPutGet(t *testing.T) {
+	blobAdapter := newTestBlobAdapter(t)
+	const workers = 8
+	const rounds = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			key := uniqueKey(fmt.Sprintf("worker-%d.txt", worker))
+			for round := 0; round < rounds; round++ {
+				content := []byte(fmt.Sprintf("worker %d round %d", worker, round))
+				err := blobAdapter.Put(key, content)
+				if err != nil {
+					errs <- fmt.Errorf("Put %s: %w", key, err)
+					return
+				}
+				got, err := blobAdapter.Get(key)
+				if err != nil {
+					errs <- fmt.Errorf("Get %s: %w", key, err)
+					return
+				}
+				if !bytes.Equal(got, content) {
+					errs <- fmt.Errorf("Get %s = %q, want %q", key, got, content)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	assertNoTempFiles(t)
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blobStorage
+
+import (
+	"fmt"
+	fileUtil "github.com/devtron-labs/central-api/internal/util"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileSystemBlobAdapterImpl keeps blobs as files under a local directory, used for dev and air gapped setups
+type FileSystemBlobAdapterImpl struct {
+	logger  *zap.SugaredLogger
+	baseDir string
+}
+
+func NewFileSystemBlobAdapterImpl(logger *zap.SugaredLogger, baseDir string) (*FileSystemBlobAdapterImpl, error) {
+	err := os.MkdirAll(baseDir, 0755)
+	if err != nil {
+		logger.Errorw("error in creating storage directory", "dir", baseDir, "err", err)
+		return nil, err
+	}
+	return &FileSystemBlobAdapterImpl{logger: logger, baseDir: baseDir}, nil
+}
+
+func (impl *FileSystemBlobAdapterImpl) Put(key string, content []byte) error {
+	path, err := impl.getPath(key)
+	if err != nil {
+		return err
+	}
+	err = fileUtil.WriteFileAtomic(path, content, 0644)
+	if err != nil {
+		impl.logger.Errorw("error in writing blob file", "key", key, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (impl *FileSystemBlobAdapterImpl) Get(key string) ([]byte, error) {
+	path, err := impl.getPath(key)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+	}
+	if err != nil {
+		impl.logger.Errorw("error in reading blob file", "key", key, "err", err)
+		return nil, err
+	}
+	return content, nil
+}
+
+// getPath resolves key inside base directory, keys are flat names and can not point outside of it
+func (impl *FileSystemBlobAdapterImpl) getPath(key string) (string, error) {
+	if len(key) == 0 || strings.ContainsAny(key, `/\`) || key == "." || key == ".." {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(impl.baseDir, key), nil
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lock
+
+import (
+	"encoding/json"
+	"github.com/devtron-labs/central-api/pkg/blobStorage"
+	"go.uber.org/zap"
+	"time"
+)
+
+// blobLockSettleDelay is the wait between writing a lease and reading it back, a replica which wrote its lease
+// at the same time either overwrote ours or was overwritten by it within this delay
+const blobLockSettleDelay = 2 * time.Second
+
+type blobLease struct {
+	Owner     string    `json:"owner"`
+	ExpiresOn time.Time `json:"expiresOn"`
+}
+
+// BlobLockImpl keeps a lease object per lock in blob storage. Buckets have no compare and swap, so a lease is
+// written and read back after a delay, the last writer wins. This is best effort, two replicas may still run
+// together in rare cases and jobs guarded by it have to be safe to repeat.
+type BlobLockImpl struct {
+	logger      *zap.SugaredLogger
+	blobAdapter blobStorage.BlobAdapter
+	ownerId     string
+	settleDelay time.Duration
+}
+
+func NewBlobLockImpl(logger *zap.SugaredLogger, blobAdapter blobStorage.BlobAdapter, ownerId string) *BlobLockImpl {
+	return &BlobLockImpl{
+		logger:      logger,
+		blobAdapter: blobAdapter,
+		ownerId:     ownerId,
+		settleDelay: blobLockSettleDelay,
+	}
+}
+
+func (impl *BlobLockImpl) TryAcquire(name string, ttl time.Duration) (func(), bool, error) {
+	key := name + ".lock"
+	lease, err := impl.getLease(key)
+	if err == nil && lease.Owner != impl.ownerId && time.Now().Before(lease.ExpiresOn) {
+		impl.logger.Debugw("lock is held by another replica", "name", name, "owner", lease.Owner, "expiresOn", lease.ExpiresOn)
+		return nil, false, nil
+	}
+	// missing or unreadable lease is taken over
+	err = impl.putLease(key, &blobLease{Owner: impl.ownerId, ExpiresOn: time.Now().Add(ttl)})
+	if err != nil {
+		return nil, false, err
+	}
+	time.Sleep(impl.settleDelay)
+	lease, err = impl.getLease(key)
+	if err != nil {
+		impl.logger.Errorw("error in reading back lock lease", "name", name, "err", err)
+		return nil, false, err
+	}
+	if lease.Owner != impl.ownerId {
+		impl.logger.Infow("lock taken by another replica", "name", name, "owner", lease.Owner)
+		return nil, false, nil
+	}
+	release := func() {
+		current, err := impl.getLease(key)
+		if err != nil || current.Owner != impl.ownerId {
+			return
+		}
+		err = impl.putLease(key, &blobLease{Owner: impl.ownerId, ExpiresOn: time.Now()})
+		if err != nil {
+			impl.logger.Warnw("error in releasing lock, it expires after ttl", "name", name, "err", err)
+		}
+	}
+	return release, true, nil
+}
+
+func (impl *BlobLockImpl) getLease(key string) (*blobLease, error) {
+	content, err := impl.blobAdapter.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	lease := &blobLease{}
+	err = json.Unmarshal(content, lease)
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+func (impl *BlobLockImpl) putLease(key string, lease *blobLease) error {
+	content, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+	err = impl.blobAdapter.Put(key, content)
+	if err != nil {
+		impl.logger.Errorw("error in writing lock lease", "key", key, "err", err)
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lock
+
+import (
+	"fmt"
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/devtron-labs/central-api/pkg/blobStorage"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"os"
+	"sync"
+	"time"
+)
+
+// DistributedLock lets a single replica run a job at a time
+type DistributedLock interface {
+	// TryAcquire does not wait for the lock, it returns false without error when another owner holds it.
+	// release has to be called once the job is done, ttl bounds how long a crashed owner keeps the lock.
+	TryAcquire(name string, ttl time.Duration) (release func(), acquired bool, err error)
+}
+
+// NewDistributedLock returns lock of configured storage backend, memory backend runs a single replica and locks in process.
+// dbConnection is nil unless storage backend is postgres.
+func NewDistributedLock(logger *zap.SugaredLogger, storageConfig *util.StorageConfig, blobAdapter blobStorage.BlobAdapter,
+	dbConnection *pg.DB) (DistributedLock, error) {
+	switch storageConfig.Backend {
+	case util.STORAGE_BACKEND_POSTGRES:
+		return NewPostgresLockImpl(logger, dbConnection), nil
+	case util.STORAGE_BACKEND_BLOB, util.STORAGE_BACKEND_FILESYSTEM:
+		return NewBlobLockImpl(logger, blobAdapter, getOwnerId()), nil
+	}
+	return NewLocalLockImpl(), nil
+}
+
+// getOwnerId identifies this replica, hostname is the pod name on kubernetes
+func getOwnerId() string {
+	hostname, err := os.Hostname()
+	if err != nil || len(hostname) == 0 {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+type LocalLockImpl struct {
+	mutex sync.Mutex
+	held  map[string]bool
+}
+
+func NewLocalLockImpl() *LocalLockImpl {
+	return &LocalLockImpl{held: make(map[string]bool)}
+}
+
+func (impl *LocalLockImpl) TryAcquire(name string, ttl time.Duration) (func(), bool, error) {
+	impl.mutex.Lock()
+	defer impl.mutex.Unlock()
+	if impl.held[name] {
+		return nil, false, nil
+	}
+	impl.held[name] = true
+	release := func() {
+		impl.mutex.Lock()
+		defer impl.mutex.Unlock()
+		delete(impl.held, name)
+	}
+	return release, true, nil
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lock
+
+import (
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"hash/fnv"
+	"time"
+)
+
+// PostgresLockImpl uses transaction level advisory locks, lock is held by an open tx and is released by postgres
+// when tx ends or the connection drops, so ttl is not needed
+type PostgresLockImpl struct {
+	logger       *zap.SugaredLogger
+	dbConnection *pg.DB
+}
+
+func NewPostgresLockImpl(logger *zap.SugaredLogger, dbConnection *pg.DB) *PostgresLockImpl {
+	return &PostgresLockImpl{logger: logger, dbConnection: dbConnection}
+}
+
+func (impl *PostgresLockImpl) TryAcquire(name string, ttl time.Duration) (func(), bool, error) {
+	tx, err := impl.dbConnection.Begin()
+	if err != nil {
+		impl.logger.Errorw("error in starting tx for advisory lock", "name", name, "err", err)
+		return nil, false, err
+	}
+	var acquired bool
+	_, err = tx.QueryOne(pg.Scan(&acquired), "SELECT pg_try_advisory_xact_lock(?)", getAdvisoryLockKey(name))
+	if err != nil || !acquired {
+		impl.rollback(tx, name)
+		if err != nil {
+			impl.logger.Errorw("error in acquiring advisory lock", "name", name, "err", err)
+		}
+		return nil, false, err
+	}
+	return func() { impl.rollback(tx, name) }, true, nil
+}
+
+func (impl *PostgresLockImpl) rollback(tx *pg.Tx, name string) {
+	err := tx.Rollback()
+	if err != nil {
+		impl.logger.Warnw("error in releasing advisory lock", "name", name, "err", err)
+	}
+}
+
+func getAdvisoryLockKey(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return int64(hash.Sum64())
+}
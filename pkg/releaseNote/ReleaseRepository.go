@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package releaseNote
+
+import (
+	"github.com/devtron-labs/central-api/common"
+	"github.com/go-pg/pg"
+	"time"
+)
+
+type Release struct {
+	tableName           struct{}                 `sql:"releases"`
+	Id                  int                      `sql:"id,pk"`
+	Repo                string                   `sql:"repo,notnull"`
+	TagName             string                   `sql:"tag_name,notnull"`
+	ReleaseName         string                   `sql:"release_name"`
+	Body                string                   `sql:"body"`
+	Prerequisite        bool                     `sql:"prerequisite,notnull"`
+	PrerequisiteMessage string                   `sql:"prerequisite_message"`
+	TagLink             string                   `sql:"tag_link"`
+	Sections            []*common.ReleaseSection `sql:"sections"`
+	Prerelease          bool                     `sql:"prerelease,notnull"`
+	Draft               bool                     `sql:"draft,notnull"`
+	CreatedAt           time.Time                `sql:"created_at,type:timestamptz"`
+	PublishedAt         time.Time                `sql:"published_at,type:timestamptz"`
+	CreatedOn           time.Time                `sql:"created_on,type:timestamptz,notnull"`
+	UpdatedOn           time.Time                `sql:"updated_on,type:timestamptz"`
+}
+
+// UnassignedRepo is repo of rows stored before multi repo support, see AssignRepo
+const UnassignedRepo = ""
+
+type ReleaseRepository interface {
+	GetConnection() *pg.DB
+	// AssignRepo sets repo of rows having UnassignedRepo, returns number of rows updated
+	AssignRepo(repo string) (int, error)
+	FindByTag(repo string, tagName string) (*Release, error)
+	// Exists returns true if repo has a non draft release
+	Exists(repo string) (bool, error)
+	// GetVersion returns hash of tag names and update times of every row of repo, it changes on every write. Rows
+	// are not read so it is much cheaper than List.
+	GetVersion(repo string) (string, error)
+	// List returns non draft releases of repo ordered by latest published first, size <= 0 returns all releases after offset
+	List(repo string, offset int, size int) ([]*Release, error)
+	// Upsert inserts the release or updates the existing row having same repo and tag_name
+	Upsert(release *Release, tx *pg.Tx) error
+	Delete(repo string, tagName string) error
+	// DeleteNotIn removes every release of repo whose tag_name is not in tagNames, no-op if tagNames is empty
+	DeleteNotIn(repo string, tagNames []string, tx *pg.Tx) error
+}
+
+type ReleaseRepositoryImpl struct {
+	dbConnection *pg.DB
+}
+
+func NewReleaseRepositoryImpl(dbConnection *pg.DB) *ReleaseRepositoryImpl {
+	return &ReleaseRepositoryImpl{dbConnection: dbConnection}
+}
+
+func (impl ReleaseRepositoryImpl) GetConnection() *pg.DB {
+	return impl.dbConnection
+}
+
+func (impl ReleaseRepositoryImpl) AssignRepo(repo string) (int, error) {
+	result, err := impl.dbConnection.Model((*Release)(nil)).
+		Set("repo = ?", repo).
+		Where("repo = ?", UnassignedRepo).
+		Update()
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+func (impl ReleaseRepositoryImpl) FindByTag(repo string, tagName string) (*Release, error) {
+	release := &Release{}
+	err := impl.dbConnection.Model(release).
+		Where("repo = ?", repo).
+		Where("tag_name = ?", tagName).
+		Select()
+	return release, err
+}
+
+func (impl ReleaseRepositoryImpl) Exists(repo string) (bool, error) {
+	return impl.dbConnection.Model((*Release)(nil)).
+		Where("repo = ?", repo).
+		Where("draft = ?", false).
+		Exists()
+}
+
+func (impl ReleaseRepositoryImpl) GetVersion(repo string) (string, error) {
+	var version string
+	query := "SELECT coalesce(md5(string_agg(tag_name || '@' || coalesce(updated_on::text, ''), ',' ORDER BY tag_name)), '')" +
+		" FROM releases WHERE repo = ?"
+	_, err := impl.dbConnection.QueryOne(pg.Scan(&version), query, repo)
+	return version, err
+}
+
+func (impl ReleaseRepositoryImpl) List(repo string, offset int, size int) ([]*Release, error) {
+	var releases []*Release
+	query := impl.dbConnection.Model(&releases).
+		Where("repo = ?", repo).
+		Where("draft = ?", false).
+		Order("published_at DESC").
+		Order("id DESC").
+		Offset(offset)
+	if size > 0 {
+		query = query.Limit(size)
+	}
+	err := query.Select()
+	return releases, err
+}
+
+func (impl ReleaseRepositoryImpl) Upsert(release *Release, tx *pg.Tx) error {
+	_, err := tx.Model(release).
+		OnConflict("(repo, tag_name) DO UPDATE").
+		Set("release_name = EXCLUDED.release_name").
+		Set("body = EXCLUDED.body").
+		Set("prerequisite = EXCLUDED.prerequisite").
+		Set("prerequisite_message = EXCLUDED.prerequisite_message").
+		Set("tag_link = EXCLUDED.tag_link").
+		Set("sections = EXCLUDED.sections").
+		Set("prerelease = EXCLUDED.prerelease").
+		Set("draft = EXCLUDED.draft").
+		Set("created_at = EXCLUDED.created_at").
+		Set("published_at = EXCLUDED.published_at").
+		Set("updated_on = EXCLUDED.updated_on").
+		Insert()
+	return err
+}
+
+func (impl ReleaseRepositoryImpl) Delete(repo string, tagName string) error {
+	_, err := impl.dbConnection.Model((*Release)(nil)).
+		Where("repo = ?", repo).
+		Where("tag_name = ?", tagName).
+		Delete()
+	return err
+}
+
+func (impl ReleaseRepositoryImpl) DeleteNotIn(repo string, tagNames []string, tx *pg.Tx) error {
+	if len(tagNames) == 0 {
+		return nil
+	}
+	_, err := tx.Model((*Release)(nil)).
+		Where("repo = ?", repo).
+		Where("tag_name NOT IN (?)", pg.In(tagNames)).
+		Delete()
+	return err
+}
@@ -17,6 +17,7 @@
 package sql
 
 import (
+	util "github.com/devtron-labs/central-api/client"
 	"go.uber.org/zap"
 	"reflect"
 	"time"
@@ -43,6 +44,15 @@ func ParseConfig() (*Config, error) {
 	return cfg, err
 }
 
+// NewStorageDbConnection returns the db connection shared by every postgres store, nil for other storage
+// backends so that they run without a database
+func NewStorageDbConnection(logger *zap.SugaredLogger, storageConfig *util.StorageConfig) (*pg.DB, error) {
+	if storageConfig.Backend != util.STORAGE_BACKEND_POSTGRES {
+		return nil, nil
+	}
+	return NewDbConnection(logger)
+}
+
 func NewDbConnection(logger *zap.SugaredLogger) (*pg.DB, error) {
 	var cfg *Config
 	var err error
@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhookDelivery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	fileUtil "github.com/devtron-labs/central-api/internal/util"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const webhookDeliveryDirName = "webhook-deliveries"
+
+// WebhookDeliveryFileSystemRepositoryImpl keeps one json file per delivery, files are replaced atomically.
+// Listing reads every file so it is meant for dev and small on-prem setups only.
+type WebhookDeliveryFileSystemRepositoryImpl struct {
+	logger *zap.SugaredLogger
+	dir    string
+	// lock makes exists check and write in Save atomic within the process
+	lock sync.Mutex
+}
+
+func NewWebhookDeliveryFileSystemRepositoryImpl(logger *zap.SugaredLogger, baseDir string) (*WebhookDeliveryFileSystemRepositoryImpl, error) {
+	dir := filepath.Join(baseDir, webhookDeliveryDirName)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		logger.Errorw("error in creating webhook delivery directory", "dir", dir, "err", err)
+		return nil, err
+	}
+	return &WebhookDeliveryFileSystemRepositoryImpl{logger: logger, dir: dir}, nil
+}
+
+func (impl *WebhookDeliveryFileSystemRepositoryImpl) Save(delivery *WebhookDelivery) (bool, error) {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+	_, err := os.Stat(impl.getPath(delivery.DeliveryId))
+	if err == nil {
+		return false, nil
+	}
+	if !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, impl.write(delivery)
+}
+
+func (impl *WebhookDeliveryFileSystemRepositoryImpl) Update(delivery *WebhookDelivery) error {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+	_, err := impl.read(impl.getPath(delivery.DeliveryId))
+	if err != nil {
+		return err
+	}
+	return impl.write(delivery)
+}
+
+func (impl *WebhookDeliveryFileSystemRepositoryImpl) FindByDeliveryId(deliveryId string) (*WebhookDelivery, error) {
+	return impl.read(impl.getPath(deliveryId))
+}
+
+func (impl *WebhookDeliveryFileSystemRepositoryImpl) List(offset int, size int, status string) ([]*WebhookDelivery, error) {
+	entries, err := os.ReadDir(impl.dir)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]*WebhookDelivery, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		delivery, err := impl.read(filepath.Join(impl.dir, entry.Name()))
+		if err != nil {
+			// file removed or replaced meanwhile
+			impl.logger.Warnw("error in reading webhook delivery file", "file", entry.Name(), "err", err)
+			continue
+		}
+		if len(status) > 0 && delivery.Status != status {
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].ReceivedOn.After(deliveries[j].ReceivedOn)
+	})
+	if offset >= len(deliveries) {
+		return []*WebhookDelivery{}, nil
+	}
+	deliveries = deliveries[offset:]
+	if size > 0 && size < len(deliveries) {
+		deliveries = deliveries[:size]
+	}
+	return deliveries, nil
+}
+
+func (impl *WebhookDeliveryFileSystemRepositoryImpl) read(path string) (*WebhookDelivery, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, pg.ErrNoRows
+	}
+	if err != nil {
+		return nil, err
+	}
+	delivery := &WebhookDelivery{}
+	err = json.Unmarshal(content, delivery)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (impl *WebhookDeliveryFileSystemRepositoryImpl) write(delivery *WebhookDelivery) error {
+	content, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	return fileUtil.WriteFileAtomic(impl.getPath(delivery.DeliveryId), content, 0644)
+}
+
+// getPath maps delivery id to sha256 hex file name, every id gets its own fixed length name and ids sent by
+// callers can never point outside of delivery directory. Files are listed by content so names are never decoded.
+func (impl *WebhookDeliveryFileSystemRepositoryImpl) getPath(deliveryId string) string {
+	hash := sha256.Sum256([]byte(deliveryId))
+	return filepath.Join(impl.dir, hex.EncodeToString(hash[:])+".json")
+}
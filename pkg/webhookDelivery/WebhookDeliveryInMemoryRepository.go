@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhookDelivery
+
+import (
+	"github.com/go-pg/pg"
+	"sync"
+)
+
+// WebhookDeliveryInMemoryRepositoryImpl keeps the latest deliveries in memory, used when no database is configured.
+// Oldest deliveries are evicted once maxSize is reached so duplicates older than that are not detected.
+type WebhookDeliveryInMemoryRepositoryImpl struct {
+	mutex      sync.RWMutex
+	maxSize    int
+	deliveries []*WebhookDelivery // latest received last
+}
+
+func NewWebhookDeliveryInMemoryRepositoryImpl(maxSize int) *WebhookDeliveryInMemoryRepositoryImpl {
+	return &WebhookDeliveryInMemoryRepositoryImpl{maxSize: maxSize}
+}
+
+func (impl *WebhookDeliveryInMemoryRepositoryImpl) Save(delivery *WebhookDelivery) (bool, error) {
+	impl.mutex.Lock()
+	defer impl.mutex.Unlock()
+	if impl.find(delivery.DeliveryId) != nil {
+		return false, nil
+	}
+	stored := *delivery
+	impl.deliveries = append(impl.deliveries, &stored)
+	if impl.maxSize > 0 && len(impl.deliveries) > impl.maxSize {
+		impl.deliveries = impl.deliveries[len(impl.deliveries)-impl.maxSize:]
+	}
+	return true, nil
+}
+
+func (impl *WebhookDeliveryInMemoryRepositoryImpl) Update(delivery *WebhookDelivery) error {
+	impl.mutex.Lock()
+	defer impl.mutex.Unlock()
+	stored := impl.find(delivery.DeliveryId)
+	if stored == nil {
+		return pg.ErrNoRows
+	}
+	stored.Body = delivery.Body
+	stored.SignatureValid = delivery.SignatureValid
+	stored.Status = delivery.Status
+	stored.Error = delivery.Error
+	stored.ReplayCount = delivery.ReplayCount
+	stored.Attempts = delivery.Attempts
+	stored.ProcessedOn = delivery.ProcessedOn
+	return nil
+}
+
+func (impl *WebhookDeliveryInMemoryRepositoryImpl) FindByDeliveryId(deliveryId string) (*WebhookDelivery, error) {
+	impl.mutex.RLock()
+	defer impl.mutex.RUnlock()
+	stored := impl.find(deliveryId)
+	if stored == nil {
+		return nil, pg.ErrNoRows
+	}
+	delivery := *stored
+	return &delivery, nil
+}
+
+func (impl *WebhookDeliveryInMemoryRepositoryImpl) List(offset int, size int, status string) ([]*WebhookDelivery, error) {
+	impl.mutex.RLock()
+	defer impl.mutex.RUnlock()
+	deliveries := make([]*WebhookDelivery, 0)
+	skipped := 0
+	for i := len(impl.deliveries) - 1; i >= 0 && (size <= 0 || len(deliveries) < size); i-- {
+		if len(status) > 0 && impl.deliveries[i].Status != status {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		delivery := *impl.deliveries[i]
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, nil
+}
+
+func (impl *WebhookDeliveryInMemoryRepositoryImpl) find(deliveryId string) *WebhookDelivery {
+	for _, delivery := range impl.deliveries {
+		if delivery.DeliveryId == deliveryId {
+			return delivery
+		}
+	}
+	return nil
+}
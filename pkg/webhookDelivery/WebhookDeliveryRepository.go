@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhookDelivery
+
+import (
+	util "github.com/devtron-labs/central-api/client"
+	"github.com/go-pg/pg"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	StatusReceived   = "received"
+	StatusQueued     = "queued"
+	StatusProcessed  = "processed"
+	StatusIgnored    = "ignored"
+	StatusRejected   = "rejected"
+	StatusFailed     = "failed"
+	StatusDeadLetter = "dead_letter"
+)
+
+type WebhookDelivery struct {
+	tableName      struct{}            `sql:"webhook_deliveries"`
+	Id             int                 `sql:"id,pk"`
+	DeliveryId     string              `sql:"delivery_id,notnull"`
+	EventType      string              `sql:"event_type"`
+	Headers        map[string][]string `sql:"headers"`
+	Body           string              `sql:"body"`
+	SignatureValid bool                `sql:"signature_valid,notnull"`
+	Status         string              `sql:"status,notnull"`
+	Error          string              `sql:"error"`
+	ReplayCount    int                 `sql:"replay_count,notnull"`
+	Attempts       int                 `sql:"attempts,notnull"`
+	ReceivedOn     time.Time           `sql:"received_on,type:timestamptz,notnull"`
+	ProcessedOn    time.Time           `sql:"processed_on,type:timestamptz"`
+}
+
+type WebhookDeliveryRepository interface {
+	// Save inserts the delivery, returns false without error when a delivery with same delivery id already exists
+	Save(delivery *WebhookDelivery) (bool, error)
+	Update(delivery *WebhookDelivery) error
+	FindByDeliveryId(deliveryId string) (*WebhookDelivery, error)
+	// List returns deliveries ordered by latest received first, empty status returns deliveries of all statuses.
+	// size <= 0 returns all the deliveries after offset.
+	List(offset int, size int, status string) ([]*WebhookDelivery, error)
+}
+
+// NewWebhookDeliveryRepository returns webhook delivery repository of configured storage backend, blob and
+// memory backends keep a bounded in memory log. dbConnection is nil unless storage backend is postgres.
+func NewWebhookDeliveryRepository(logger *zap.SugaredLogger, storageConfig *util.StorageConfig,
+	webhookDeliveryConfig *util.WebhookDeliveryConfig, dbConnection *pg.DB) (WebhookDeliveryRepository, error) {
+	switch storageConfig.Backend {
+	case util.STORAGE_BACKEND_POSTGRES:
+		return NewWebhookDeliveryRepositoryImpl(dbConnection), nil
+	case util.STORAGE_BACKEND_FILESYSTEM:
+		repositoryImpl, err := NewWebhookDeliveryFileSystemRepositoryImpl(logger, storageConfig.FileSystemDir)
+		if err != nil {
+			return nil, err
+		}
+		return repositoryImpl, nil
+	}
+	return NewWebhookDeliveryInMemoryRepositoryImpl(webhookDeliveryConfig.InMemoryLogSize), nil
+}
+
+type WebhookDeliveryRepositoryImpl struct {
+	dbConnection *pg.DB
+}
+
+func NewWebhookDeliveryRepositoryImpl(dbConnection *pg.DB) *WebhookDeliveryRepositoryImpl {
+	return &WebhookDeliveryRepositoryImpl{dbConnection: dbConnection}
+}
+
+func (impl WebhookDeliveryRepositoryImpl) Save(delivery *WebhookDelivery) (bool, error) {
+	result, err := impl.dbConnection.Model(delivery).
+		OnConflict("(delivery_id) DO NOTHING").
+		Insert()
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (impl WebhookDeliveryRepositoryImpl) Update(delivery *WebhookDelivery) error {
+	_, err := impl.dbConnection.Model(delivery).
+		Column("body", "signature_valid", "status", "error", "replay_count", "attempts", "processed_on").
+		Where("delivery_id = ?", delivery.DeliveryId).
+		Update()
+	return err
+}
+
+func (impl WebhookDeliveryRepositoryImpl) FindByDeliveryId(deliveryId string) (*WebhookDelivery, error) {
+	delivery := &WebhookDelivery{}
+	err := impl.dbConnection.Model(delivery).
+		Where("delivery_id = ?", deliveryId).
+		Select()
+	return delivery, err
+}
+
+func (impl WebhookDeliveryRepositoryImpl) List(offset int, size int, status string) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	query := impl.dbConnection.Model(&deliveries)
+	if len(status) > 0 {
+		query = query.Where("status = ?", status)
+	}
+	query = query.
+		Order("received_on DESC").
+		Order("id DESC").
+		Offset(offset)
+	if size > 0 {
+		query = query.Limit(size)
+	}
+	err := query.Select()
+	return deliveries, err
+}
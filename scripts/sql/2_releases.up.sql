@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+-- Sequence and defined type
+CREATE SEQUENCE IF NOT EXISTS id_releases;
+
+-- Table Definition
+CREATE TABLE IF NOT EXISTS "public"."releases"
+(
+    "id"                   int4         NOT NULL DEFAULT nextval('id_releases'::regclass),
+    "tag_name"             varchar(250) NOT NULL,
+    "release_name"         text,
+    "body"                 text,
+    "prerequisite"         bool         NOT NULL DEFAULT false,
+    "prerequisite_message" text,
+    "tag_link"             text,
+    "created_at"           timestamptz,
+    "published_at"         timestamptz,
+    "created_on"           timestamptz  NOT NULL,
+    "updated_on"           timestamptz,
+    PRIMARY KEY ("id")
+);
+
+--> one row per tag, used as conflict target for upserts
+CREATE UNIQUE INDEX IF NOT EXISTS releases_tag_name_unique ON releases (tag_name);
+
+--> releases are always listed latest published first
+CREATE INDEX IF NOT EXISTS releases_published_at_idx ON releases (published_at DESC);
+
+--> copy releases from the active release note blob row
+INSERT INTO releases (tag_name, release_name, body, prerequisite, prerequisite_message, tag_link, created_at, published_at, created_on, updated_on)
+SELECT r ->> 'tagName',
+       r ->> 'releaseName',
+       r ->> 'body',
+       COALESCE((r ->> 'prerequisite')::bool, false),
+       r ->> 'prerequisiteMessage',
+       r ->> 'tagLink',
+       (r ->> 'createdAt')::timestamptz,
+       (r ->> 'publishedAt')::timestamptz,
+       now(),
+       now()
+FROM release_notes rn,
+     json_array_elements(rn.release_note::json) r
+WHERE rn.is_active = true
+  AND COALESCE(r ->> 'tagName', '') <> ''
+ON CONFLICT (tag_name) DO NOTHING;
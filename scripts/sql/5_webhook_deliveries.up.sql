@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+-- Sequence and defined type
+CREATE SEQUENCE IF NOT EXISTS id_webhook_deliveries;
+
+-- Table Definition
+CREATE TABLE IF NOT EXISTS "public"."webhook_deliveries"
+(
+    "id"              int4         NOT NULL DEFAULT nextval('id_webhook_deliveries'::regclass),
+    "delivery_id"     varchar(100) NOT NULL,
+    "event_type"      varchar(100),
+    "headers"         text,
+    "body"            text,
+    "signature_valid" bool         NOT NULL DEFAULT false,
+    "status"          varchar(50)  NOT NULL,
+    "error"           text,
+    "replay_count"    int4         NOT NULL DEFAULT 0,
+    "received_on"     timestamptz  NOT NULL,
+    "processed_on"    timestamptz,
+    PRIMARY KEY ("id")
+);
+
+--> github retries keep the same delivery id, used to skip duplicates
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_delivery_id_unique ON webhook_deliveries (delivery_id);
+
+--> deliveries are always listed latest received first
+CREATE INDEX IF NOT EXISTS webhook_deliveries_received_on_idx ON webhook_deliveries (received_on DESC);
@@ -0,0 +1,30 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+---- DELETES releases of every repo except the one owning the oldest row, i.e. the primary repo before multi repo
+---- support, as tags are unique again only for a single repo. Releases of the other repos are lost and are only
+---- fetched again from release source after upgrading.
+DELETE FROM releases WHERE repo <> (SELECT repo FROM releases ORDER BY id LIMIT 1);
+
+DROP INDEX IF EXISTS releases_repo_published_at_idx;
+CREATE INDEX IF NOT EXISTS releases_published_at_idx ON releases (published_at DESC);
+
+DROP INDEX IF EXISTS releases_repo_tag_name_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS releases_tag_name_unique ON releases (tag_name);
+
+---- DROP column
+ALTER TABLE "public"."releases" DROP COLUMN IF EXISTS "repo";
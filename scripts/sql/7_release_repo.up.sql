@@ -0,0 +1,29 @@
+/*
+ * Copyright (c) 2024. Devtron Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+--> owner/repo of the release. Rows stored before multi repo support are left with empty repo, central-api assigns
+--> them to the primary repo of configured release source on startup.
+ALTER TABLE "public"."releases" ADD COLUMN IF NOT EXISTS "repo" varchar(250) NOT NULL DEFAULT '';
+ALTER TABLE "public"."releases" ALTER COLUMN "repo" DROP DEFAULT;
+
+--> one row per tag of every repo, used as conflict target for upserts
+DROP INDEX IF EXISTS releases_tag_name_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS releases_repo_tag_name_unique ON releases (repo, tag_name);
+
+--> releases are always listed per repo latest published first
+DROP INDEX IF EXISTS releases_published_at_idx;
+CREATE INDEX IF NOT EXISTS releases_repo_published_at_idx ON releases (repo, published_at DESC);
@@ -11,6 +11,10 @@ import (
 	"github.com/devtron-labs/central-api/client"
 	"github.com/devtron-labs/central-api/internal/logger"
 	"github.com/devtron-labs/central-api/pkg"
+	"github.com/devtron-labs/central-api/pkg/blobStorage"
+	"github.com/devtron-labs/central-api/pkg/lock"
+	"github.com/devtron-labs/central-api/pkg/sql"
+	"github.com/devtron-labs/central-api/pkg/webhookDelivery"
 	"github.com/devtron-labs/common-lib/blob-storage"
 )
 
@@ -34,14 +38,65 @@ func InitializeApp() (*App, error) {
 		return nil, err
 	}
 	blobStorageServiceImpl := blob_storage.NewBlobStorageServiceImpl(sugaredLogger)
-	releaseNoteServiceImpl, err := pkg.NewReleaseNoteServiceImpl(sugaredLogger, gitHubClient, moduleConfig, blobConfigVariables, blobStorageServiceImpl)
+	storageConfig, err := util.NewStorageConfig(sugaredLogger, blobConfigVariables)
+	if err != nil {
+		return nil, err
+	}
+	blobAdapter, err := blobStorage.NewBlobAdapter(sugaredLogger, storageConfig, blobConfigVariables, blobStorageServiceImpl)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.NewStorageDbConnection(sugaredLogger, storageConfig)
 	if err != nil {
 		return nil, err
 	}
 	webhookSecretValidatorImpl := pkg.NewWebhookSecretValidatorImpl(sugaredLogger, gitHubClient)
+	releaseSourceConfig, err := util.NewReleaseSourceConfig(sugaredLogger)
+	if err != nil {
+		return nil, err
+	}
+	releaseSource, err := pkg.NewReleaseSource(sugaredLogger, releaseSourceConfig, gitHubClient, webhookSecretValidatorImpl)
+	if err != nil {
+		return nil, err
+	}
+	releaseChannelConfig, err := util.NewReleaseChannelConfig(sugaredLogger)
+	if err != nil {
+		return nil, err
+	}
+	releaseChannelClassifierImpl, err := pkg.NewReleaseChannelClassifierImpl(sugaredLogger, releaseChannelConfig)
+	if err != nil {
+		return nil, err
+	}
+	releaseRepositoryRegistryImpl, err := pkg.NewReleaseRepositoryRegistryImpl(sugaredLogger, storageConfig, blobAdapter, db, releaseSource, releaseChannelClassifierImpl)
+	if err != nil {
+		return nil, err
+	}
+	releaseNoteServiceImpl := pkg.NewReleaseNoteServiceImpl(sugaredLogger, gitHubClient, moduleConfig, releaseRepositoryRegistryImpl, releaseSource, releaseChannelClassifierImpl)
 	ciBuildMetadataServiceImpl := pkg.NewCiBuildMetadataServiceImpl(sugaredLogger)
-	restHandlerImpl := api.NewRestHandlerImpl(sugaredLogger, releaseNoteServiceImpl, webhookSecretValidatorImpl, gitHubClient, ciBuildMetadataServiceImpl)
+	upgradePathServiceImpl := pkg.NewUpgradePathServiceImpl(sugaredLogger, releaseNoteServiceImpl)
+	releaseNoteRenderServiceImpl := pkg.NewReleaseNoteRenderServiceImpl(sugaredLogger, releaseSource)
+	webhookDeliveryConfig, err := util.NewWebhookDeliveryConfig(sugaredLogger)
+	if err != nil {
+		return nil, err
+	}
+	webhookDeliveryRepository, err := webhookDelivery.NewWebhookDeliveryRepository(sugaredLogger, storageConfig, webhookDeliveryConfig, db)
+	if err != nil {
+		return nil, err
+	}
+	webhookDeliveryServiceImpl := pkg.NewWebhookDeliveryServiceImpl(sugaredLogger, releaseNoteServiceImpl, webhookDeliveryRepository, releaseSource, webhookDeliveryConfig)
+	webhookQueueServiceImpl := pkg.NewWebhookQueueServiceImpl(sugaredLogger, webhookDeliveryConfig, releaseNoteServiceImpl, webhookDeliveryServiceImpl, releaseSource)
+	webhookEventRouterImpl := pkg.NewWebhookEventRouterImpl(sugaredLogger, gitHubClient, webhookDeliveryServiceImpl, webhookQueueServiceImpl)
+	restHandlerImpl := api.NewRestHandlerImpl(sugaredLogger, releaseNoteServiceImpl, releaseSource, gitHubClient, ciBuildMetadataServiceImpl, upgradePathServiceImpl, releaseNoteRenderServiceImpl, webhookDeliveryServiceImpl, webhookDeliveryConfig, webhookEventRouterImpl)
 	muxRouter := api.NewMuxRouter(sugaredLogger, restHandlerImpl)
-	app := NewApp(muxRouter, sugaredLogger)
+	reconcileConfig, err := util.NewReconcileConfig(sugaredLogger)
+	if err != nil {
+		return nil, err
+	}
+	distributedLock, err := lock.NewDistributedLock(sugaredLogger, storageConfig, blobAdapter, db)
+	if err != nil {
+		return nil, err
+	}
+	releaseReconcileServiceImpl := pkg.NewReleaseReconcileServiceImpl(sugaredLogger, reconcileConfig, releaseSource, releaseNoteServiceImpl, releaseRepositoryRegistryImpl, distributedLock)
+	app := NewApp(muxRouter, sugaredLogger, webhookQueueServiceImpl, webhookDeliveryConfig, releaseReconcileServiceImpl)
 	return app, nil
 }